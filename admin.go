@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Admin ====================
+
+// adminConfig holds the admin credentials; admin endpoints are disabled without them
+type adminConfig struct {
+	username string // ADMIN_USERNAME + ADMIN_PASSWORD: basic auth
+	password string
+	token    string // ADMIN_TOKEN: Authorization: Bearer
+}
+
+// loadAdminConfig reads ADMIN_TOKEN / ADMIN_USERNAME / ADMIN_PASSWORD; nil when none is set
+func loadAdminConfig() (*adminConfig, error) {
+	cfg := &adminConfig{
+		username: os.Getenv("ADMIN_USERNAME"),
+		password: os.Getenv("ADMIN_PASSWORD"),
+		token:    os.Getenv("ADMIN_TOKEN"),
+	}
+	if (cfg.username == "") != (cfg.password == "") {
+		return nil, fmt.Errorf("ADMIN_USERNAME and ADMIN_PASSWORD must be set together")
+	}
+	if cfg.username == "" && cfg.token == "" {
+		return nil, nil
+	}
+	return cfg, nil
+}
+
+// loadMaxConcurrent reads MAX_CONCURRENT_TESTS (0 = unlimited)
+func loadMaxConcurrent() (int, error) {
+	v := os.Getenv("MAX_CONCURRENT_TESTS")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid MAX_CONCURRENT_TESTS %q", v)
+	}
+	return n, nil
+}
+
+// authorized checks the request credentials in constant time
+func (c *adminConfig) authorized(r *http.Request) bool {
+	if c.token != "" {
+		if auth := r.Header.Get("Authorization"); len(auth) > 7 && auth[:7] == "Bearer " &&
+			subtle.ConstantTimeCompare([]byte(auth[7:]), []byte(c.token)) == 1 {
+			return true
+		}
+	}
+	if c.username != "" {
+		if user, pass, ok := r.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(c.username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(pass), []byte(c.password)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// adminMiddleware rejects requests without admin credentials
+func adminMiddleware(cfg *adminConfig, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !cfg.authorized(r) {
+			if cfg.username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="go-speedtest admin"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="go-speedtest admin"`)
+			}
+			slog.WarnContext(r.Context(), "admin: unauthorized", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Admin credentials required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// registerAdminRoutes adds the admin endpoints (not in /openapi.json)
+func registerAdminRoutes(mux *routeMux, cfg *adminConfig, stack middleware) {
+	mux.handle("/admin/tests", stack(adminMiddleware(cfg, adminTestsHandler)))
+	mux.handle("/admin/tests/{id}/cancel", stack(adminMiddleware(cfg, adminCancelHandler)))
+}
+
+// adminTestsHandler - GET /admin/tests
+// Queued and running tests from every API, oldest first
+func adminTestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	now := time.Now()
+	response := api.AdminTestsResponse{MaxConcurrent: speedTester.MaxConcurrent, Tests: []*api.AdminTest{}}
+	for _, t := range speedTester.ActiveTests() {
+		test := &api.AdminTest{
+			ID:        t.ID,
+			State:     t.State,
+			Phase:     t.Phase,
+			Requester: t.Requester,
+			ServerID:  t.ServerID,
+			Sponsor:   t.Sponsor,
+			Location:  t.Location,
+			SpeedMbps: t.SpeedMbps,
+			QueuedAt:  t.Queued.UnixMilli(),
+		}
+		if t.State == tester.StateRunning {
+			response.Running++
+			test.ElapsedSec = now.Sub(t.Started).Seconds()
+			test.WaitedSec = t.Started.Sub(t.Queued).Seconds()
+		} else {
+			response.Queued++
+			test.WaitedSec = now.Sub(t.Queued).Seconds()
+		}
+		response.Tests = append(response.Tests, test)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// adminCancelHandler - POST /admin/tests/{id}/cancel
+// The test's requester gets a "cancelled" error (v2: 409)
+func adminCancelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	id := r.PathValue("id")
+	if !speedTester.Cancel(id) {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no queued or running test %q", id))
+		return
+	}
+	slog.InfoContext(r.Context(), "admin: test cancelled", "test_id", id, "remote", r.RemoteAddr)
+	writeJSON(w, http.StatusAccepted, api.AdminCancelResponse{ID: id, Status: "cancelling"})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminAuthorized(t *testing.T) {
+	token := &adminConfig{token: "s3cret"}
+	basic := &adminConfig{username: "admin", password: "pw"}
+	both := &adminConfig{username: "admin", password: "pw", token: "s3cret"}
+
+	for _, tc := range []struct {
+		name string
+		cfg  *adminConfig
+		set  func(r *http.Request)
+		want bool
+	}{
+		{"no credentials", both, func(r *http.Request) {}, false},
+		{"bearer", token, func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, true},
+		{"wrong bearer", token, func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cre") }, false},
+		{"bearer prefix only", token, func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") }, false},
+		{"token without scheme", token, func(r *http.Request) { r.Header.Set("Authorization", "s3cret") }, false},
+		{"lowercase scheme", token, func(r *http.Request) { r.Header.Set("Authorization", "bearer s3cret") }, false},
+		{"basic", basic, func(r *http.Request) { r.SetBasicAuth("admin", "pw") }, true},
+		{"wrong password", basic, func(r *http.Request) { r.SetBasicAuth("admin", "p") }, false},
+		{"wrong user", basic, func(r *http.Request) { r.SetBasicAuth("root", "pw") }, false},
+		{"basic when only token set", token, func(r *http.Request) { r.SetBasicAuth("", "s3cret") }, false},
+		{"bearer when only basic set", basic, func(r *http.Request) { r.Header.Set("Authorization", "Bearer pw") }, false},
+		{"both: bearer", both, func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, true},
+		{"both: basic", both, func(r *http.Request) { r.SetBasicAuth("admin", "pw") }, true},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/admin/tests", nil)
+		tc.set(r)
+		if got := tc.cfg.authorized(r); got != tc.want {
+			t.Errorf("%s: authorized = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLoadAdminConfig(t *testing.T) {
+	for _, tc := range []struct {
+		user, pass, token string
+		enabled, err      bool
+	}{
+		{"", "", "", false, false},
+		{"", "", "tok", true, false},
+		{"admin", "pw", "", true, false},
+		{"admin", "", "", false, true},
+		{"", "pw", "tok", false, true},
+	} {
+		t.Setenv("ADMIN_USERNAME", tc.user)
+		t.Setenv("ADMIN_PASSWORD", tc.pass)
+		t.Setenv("ADMIN_TOKEN", tc.token)
+		cfg, err := loadAdminConfig()
+		if (err != nil) != tc.err || (cfg != nil) != tc.enabled {
+			t.Errorf("%q/%q/%q: config %v, error %v; want enabled=%v error=%v",
+				tc.user, tc.pass, tc.token, cfg, err, tc.enabled, tc.err)
+		}
+	}
+}
+
+func TestAdminRoutesRequireCredentials(t *testing.T) {
+	mux := testMux() // admin token "test"
+	for _, tc := range []struct {
+		method, path, auth string
+		status             int
+	}{
+		{http.MethodGet, "/admin/tests", "", http.StatusUnauthorized},
+		{http.MethodGet, "/admin/tests", "Bearer wrong", http.StatusUnauthorized},
+		{http.MethodPost, "/admin/tests/abc/cancel", "", http.StatusUnauthorized},
+		{http.MethodGet, "/admin/tests", "Bearer test", http.StatusOK},
+		{http.MethodPost, "/admin/tests/abc/cancel", "Bearer test", http.StatusNotFound},
+		{http.MethodGet, "/admin/tests/abc/cancel", "Bearer test", http.StatusMethodNotAllowed},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.auth != "" {
+			r.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		if rec.Code != tc.status {
+			t.Errorf("%s %s (%q): status %d, want %d", tc.method, tc.path, tc.auth, rec.Code, tc.status)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: 401 without WWW-Authenticate", tc.method, tc.path)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+// ==================== Admin ====================
+
+// AdminTest is a queued or running test in GET /admin/tests
+type AdminTest struct {
+	ID         string  `json:"id"`
+	State      string  `json:"state"` // "queued" or "running"
+	Phase      string  `json:"phase"` // "ping", "download" or "upload"
+	Requester  string  `json:"requester,omitempty"`
+	ServerID   string  `json:"server_id,omitempty"`
+	Sponsor    string  `json:"sponsor,omitempty"`
+	Location   string  `json:"location,omitempty"`
+	ElapsedSec float64 `json:"elapsed_sec"` // Running time (0 while queued)
+	WaitedSec  float64 `json:"waited_sec"`  // Time spent in the queue
+	SpeedMbps  float64 `json:"speed_mbps"`  // Latest progress sample
+	QueuedAt   int64   `json:"queued_at"`   // Unix ms
+}
+
+// AdminTestsResponse is the GET /admin/tests body (oldest first)
+type AdminTestsResponse struct {
+	Running       int          `json:"running"`
+	Queued        int          `json:"queued"`
+	MaxConcurrent int          `json:"max_concurrent"` // 0 = unlimited
+	Tests         []*AdminTest `json:"tests"`
+}
+
+// AdminCancelResponse is the POST /admin/tests/{id}/cancel body
+type AdminCancelResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // "cancelling"
+}
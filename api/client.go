@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== Client ====================
+
+const (
+	DefaultMaxRetries = 2
+	DefaultRetryWait  = time.Second
+)
+
+// Client calls a GO-Speedtest server.
+// Requests are bounded by their context; HTTPClient should not set a Timeout
+// shorter than a test (download/upload take 5-30 seconds).
+type Client struct {
+	BaseURL    string       // e.g. "http://127.0.0.1:8645"
+	HTTPClient *http.Client // nil = http.DefaultClient
+
+	// MaxRetries is how often a failed request is retried. Metadata calls
+	// (Status, Servers, Whoami, Links, History) are retried on network
+	// errors and 502/503/504. Tests (Ping, Download, Upload and streams) may already
+	// have run when they fail, so they are only retried when the connection
+	// could not be made or the error code says the test never started (see
+	// APIError.RetrySafe); streams never after the first event.
+	MaxRetries int
+	RetryWait  time.Duration // first backoff, doubled per attempt
+}
+
+// NewClient returns a Client for baseURL with default retries
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		MaxRetries: DefaultMaxRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+}
+
+// Options selects how a test runs; zero values use the server defaults
+type Options struct {
+	ServerID  string
+	City      string   // Assumed client city (server's cities.csv)
+	Lat, Lon  *float64 // Assumed client coordinates; both or neither
+	IPVersion string   // "4" or "6"; "both" only for streams
+	Link      string   // Named link from SPEEDTEST_LINKS
+	Proxy     string   // Proxy name from SPEEDTEST_PROXIES or proxy URL
+
+	Duration time.Duration // SSE streams only, whole seconds (server max 30s)
+}
+
+// Query returns the options as API query parameters
+func (o *Options) Query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("server_id", o.ServerID)
+	set("city", o.City)
+	if o.Lat != nil {
+		q.Set("lat", strconv.FormatFloat(*o.Lat, 'f', -1, 64))
+	}
+	if o.Lon != nil {
+		q.Set("lon", strconv.FormatFloat(*o.Lon, 'f', -1, 64))
+	}
+	set("ip_version", o.IPVersion)
+	set("link", o.Link)
+	set("proxy", o.Proxy)
+	if o.Duration > 0 {
+		q.Set("duration", strconv.Itoa(int(o.Duration.Seconds())))
+	}
+	return q
+}
+
+// APIError is a non-200 response, decoded from ErrorResponse when possible
+type APIError struct {
+	StatusCode int
+	Code       string // ErrorResponse.Error, e.g. "invalid_request"
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Temporary reports whether retrying the request may succeed
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetrySafe reports whether the server failed before starting the test
+// (no server catalogue or no usable server), so a test request can be
+// retried without running the test twice
+func (e *APIError) RetrySafe() bool {
+	switch e.Code {
+	case CodeCatalogueUnavailable, CodeNoServers:
+		return true
+	}
+	return false
+}
+
+// decodeAPIError turns a non-200 response into an *APIError
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var body ErrorResponse
+	if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body) == nil {
+		apiErr.Code = body.Error
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
+
+// ==================== Endpoints ====================
+
+// Status calls GET /
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	var out StatusResponse
+	if err := c.getJSON(ctx, "/", nil, retryMetadata, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Ping calls GET /speedtest/ping
+func (c *Client) Ping(ctx context.Context, opts *Options) (*PingResponse, error) {
+	var out PingResponse
+	if err := c.getJSON(ctx, "/speedtest/ping", opts.testQuery(), retryTest, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Download calls GET /speedtest/download
+func (c *Client) Download(ctx context.Context, opts *Options) (*DownloadResponse, error) {
+	var out DownloadResponse
+	if err := c.getJSON(ctx, "/speedtest/download", opts.testQuery(), retryTest, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Upload calls GET /speedtest/upload
+func (c *Client) Upload(ctx context.Context, opts *Options) (*UploadResponse, error) {
+	var out UploadResponse
+	if err := c.getJSON(ctx, "/speedtest/upload", opts.testQuery(), retryTest, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Servers calls GET /speedtest/servers (closest servers first)
+func (c *Client) Servers(ctx context.Context, opts *Options) (*ServersResponse, error) {
+	var out ServersResponse
+	if err := c.getJSON(ctx, "/speedtest/servers", opts.testQuery(), retryMetadata, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Whoami calls GET /speedtest/whoami; refresh bypasses the server cache
+func (c *Client) Whoami(ctx context.Context, opts *Options, refresh bool) (*ClientInfo, error) {
+	q := opts.testQuery()
+	if refresh {
+		q.Set("refresh", "1")
+	}
+	var out ClientInfo
+	if err := c.getJSON(ctx, "/speedtest/whoami", q, retryMetadata, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Links calls GET /speedtest/links
+func (c *Client) Links(ctx context.Context) (*LinksResponse, error) {
+	var out LinksResponse
+	if err := c.getJSON(ctx, "/speedtest/links", nil, retryMetadata, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// History calls GET /speedtest/history (newest first); limit 0 returns
+// every entry the server keeps. Each Result is decoded into a
+// *PingResponse, *DownloadResponse or *UploadResponse by Type; entries of
+// other types keep a nil Result.
+func (c *Client) History(ctx context.Context, limit int) (*HistoryResponse, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var raw struct {
+		Count   int `json:"count"`
+		Entries []struct {
+			HistoryEntry
+			Result json.RawMessage `json:"result"`
+		} `json:"entries"`
+	}
+	if err := c.getJSON(ctx, "/speedtest/history", q, retryMetadata, &raw); err != nil {
+		return nil, err
+	}
+
+	out := &HistoryResponse{Count: raw.Count, Entries: make([]*HistoryEntry, 0, len(raw.Entries))}
+	for _, e := range raw.Entries {
+		entry := e.HistoryEntry
+		var result interface{}
+		switch entry.Type {
+		case "ping":
+			result = &PingResponse{}
+		case "download":
+			result = &DownloadResponse{}
+		case "upload":
+			result = &UploadResponse{}
+		}
+		if result != nil {
+			if err := json.Unmarshal(e.Result, result); err != nil {
+				return nil, fmt.Errorf("history entry %s: %w", entry.ID, err)
+			}
+			entry.Result = result
+		}
+		out.Entries = append(out.Entries, &entry)
+	}
+	return out, nil
+}
+
+// testQuery is Query for the JSON endpoints, which reject ip_version=both
+// with a different body shape; the typed methods only support 4 and 6.
+func (o *Options) testQuery() url.Values {
+	q := o.Query()
+	q.Del("duration")
+	return q
+}
+
+// ==================== Requests ====================
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// getJSON GETs path and decodes a 200 response into out
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, retry retryPolicy, out interface{}) error {
+	if q.Get("ip_version") == "both" {
+		return errors.New("ip_version both is only supported by streams")
+	}
+	resp, err := c.get(ctx, path, q, "application/json", retry)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// get sends a GET, retrying failures retry accepts; the caller closes the
+// body of the 200 response
+func (c *Client) get(ctx context.Context, path string, q url.Values, accept string, retry retryPolicy) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(ctx, u, accept)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= c.MaxRetries || ctx.Err() != nil || !retry(err) {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// do sends one request, turning non-200 responses into *APIError
+func (c *Client) do(ctx context.Context, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, decodeAPIError(resp)
+	}
+	return resp, nil
+}
+
+// retryPolicy reports whether a failed request is worth another attempt
+type retryPolicy func(err error) bool
+
+// retryMetadata retries idempotent calls on network errors and 502/503/504
+func retryMetadata(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryTest retries a test only when it cannot have started: the
+// connection was never made, or the server says so (APIError.RetrySafe)
+func retryTest(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetrySafe()
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
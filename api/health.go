@@ -0,0 +1,60 @@
+package api
+
+// ==================== Health ====================
+
+// Readiness check states
+const (
+	CheckOK   = "ok"
+	CheckFail = "fail"
+)
+
+// HealthResponse is the GET /healthz liveness body
+type HealthResponse struct {
+	Status    string  `json:"status"` // always "alive"
+	Version   string  `json:"version"`
+	UptimeSec float64 `json:"uptime_sec"`
+}
+
+// ReadyResponse is the GET /readyz body, sent with 503 when a check fails
+type ReadyResponse struct {
+	Status string      `json:"status"` // "ready" or "not_ready"
+	Checks ReadyChecks `json:"checks"`
+}
+
+// ReadyChecks are the dependency checks behind /readyz
+type ReadyChecks struct {
+	Catalogue   CatalogueCheck   `json:"catalogue"`
+	Storage     StorageCheck     `json:"storage"`
+	Coordinator CoordinatorCheck `json:"coordinator"`
+}
+
+// CatalogueCheck: the Ookla server catalogue was fetched recently
+type CatalogueCheck struct {
+	Status      string  `json:"status"` // "ok" or "fail"
+	Message     string  `json:"message,omitempty"`
+	Servers     int     `json:"servers"`                // in the last successful fetch
+	LastFetch   int64   `json:"last_fetch,omitempty"`   // Unix ms of the last successful fetch
+	AgeSec      float64 `json:"age_sec,omitempty"`      // since the last successful fetch
+	MaxAgeSec   float64 `json:"max_age_sec"`            // older catalogues fail the check
+	LastError   string  `json:"last_error,omitempty"`   // of the last attempt
+	LastAttempt int64   `json:"last_attempt,omitempty"` // Unix ms
+}
+
+// StorageCheck: the history store can persist results
+type StorageCheck struct {
+	Status    string `json:"status"` // "ok" or "fail"
+	Message   string `json:"message,omitempty"`
+	Backend   string `json:"backend"` // "memory" or "file"
+	Path      string `json:"path,omitempty"`
+	Entries   int    `json:"entries"`
+	LastError string `json:"last_error,omitempty"` // last failed write, cleared by the next successful one
+}
+
+// CoordinatorCheck: test slot usage; informational, always "ok"
+type CoordinatorCheck struct {
+	Status        string `json:"status"` // always "ok"
+	Message       string `json:"message,omitempty"`
+	Running       int    `json:"running"`
+	Queued        int    `json:"queued"`
+	MaxConcurrent int    `json:"max_concurrent"` // 0 = unlimited
+}
@@ -0,0 +1,36 @@
+package api
+
+// ==================== History ====================
+
+// Sample is one point of a test's throughput series
+type Sample struct {
+	TimestampMs     int64   `json:"timestamp"`   // Unix ms
+	ElapsedSec      float64 `json:"elapsed_sec"` // Since the transfer started
+	SpeedMbps       float64 `json:"speed_mbps"`  // Instantaneous speed
+	Bytes           int64   `json:"bytes"`       // Cumulative bytes transferred
+	LoadedLatencyMs float64 `json:"loaded_latency_ms,omitempty"`
+}
+
+// HistoryEntry is a completed test. Result is the PingResponse,
+// DownloadResponse or UploadResponse the test returned.
+type HistoryEntry struct {
+	ID          string      `json:"id"`   // Same as the result's test_id
+	Type        string      `json:"type"` // "ping", "download" or "upload"
+	Timestamp   int64       `json:"timestamp"`
+	SampleCount int         `json:"sample_count"`
+	Result      interface{} `json:"result"`
+}
+
+// HistoryResponse is the GET /speedtest/history body (newest first)
+type HistoryResponse struct {
+	Count   int             `json:"count"`
+	Entries []*HistoryEntry `json:"entries"`
+}
+
+// SamplesResponse is the GET /speedtest/history/{id}/samples body
+type SamplesResponse struct {
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Count   int      `json:"count"`
+	Samples []Sample `json:"samples"`
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ==================== SSE Streams ====================
+
+// Stream is a running SSE test. Events is closed when the stream ends;
+// Err then reports why (nil after a final complete, error or summary event).
+type Stream struct {
+	Events <-chan StreamEvent
+
+	err    error
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// Err returns the stream error; it blocks until Events is closed
+func (s *Stream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close stops the stream and waits for it to end
+func (s *Stream) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// DownloadStream calls GET /speedtest/download/stream
+func (c *Client) DownloadStream(ctx context.Context, opts *Options) (*Stream, error) {
+	return c.stream(ctx, "/speedtest/download/stream", opts)
+}
+
+// UploadStream calls GET /speedtest/upload/stream
+func (c *Client) UploadStream(ctx context.Context, opts *Options) (*Stream, error) {
+	return c.stream(ctx, "/speedtest/upload/stream", opts)
+}
+
+// stream connects (with retries) and parses events into the Events channel
+func (c *Client) stream(ctx context.Context, path string, opts *Options) (*Stream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	resp, err := c.get(ctx, path, opts.Query(), "text/event-stream", retryTest)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	events := make(chan StreamEvent)
+	s := &Stream{Events: events, done: make(chan struct{}), cancel: cancel}
+	final := finalEvent(opts)
+
+	go func() {
+		defer close(s.done)
+		defer close(events)
+		defer cancel()
+		defer resp.Body.Close()
+
+		s.err = ReadSSE(resp.Body, func(ev StreamEvent) bool {
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return false
+			}
+			return !final(ev)
+		})
+		if s.err == nil && ctx.Err() != nil {
+			s.err = ctx.Err()
+		}
+	}()
+	return s, nil
+}
+
+// finalEvent reports whether ev ends the stream for opts
+func finalEvent(opts *Options) func(StreamEvent) bool {
+	if opts != nil && opts.IPVersion == "both" {
+		// One start/complete (or error) per family, then the summary. An
+		// error without ip_version (e.g. server selection) ends the stream.
+		return func(ev StreamEvent) bool {
+			return ev.Type == EventSummary || (ev.Type == EventError && ev.IPVersion == "")
+		}
+	}
+	return func(ev StreamEvent) bool { return ev.Type == EventComplete || ev.Type == EventError }
+}
+
+// ReadSSE parses "data:" lines of an SSE stream until fn returns false or EOF.
+// EOF before fn returned false is reported as io.ErrUnexpectedEOF.
+func ReadSSE(r io.Reader, fn func(StreamEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // comments (padding), blank separators
+		}
+		var ev StreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		if !fn(ev) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
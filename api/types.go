@@ -0,0 +1,159 @@
+// Package api contains the GO-Speedtest HTTP API types and a typed Go client.
+//
+// The server (package main) uses these types for its responses, so they
+// always match what the API returns.
+package api
+
+// ==================== Response Structs ====================
+
+// PingResponse represents ping test result
+type PingResponse struct {
+	TestID     string  `json:"test_id,omitempty"`
+	Status     string  `json:"status,omitempty"` // "success" (v1 only)
+	Latency    float64 `json:"latency_ms"`
+	ServerID   string  `json:"server_id"`
+	Sponsor    string  `json:"sponsor"`  // ISP name (e.g., "Mamura")
+	Location   string  `json:"location"` // City name (e.g., "Solo")
+	ServerHost string  `json:"server_host"`
+	Country    string  `json:"country"`
+	Distance   float64 `json:"distance_km"`
+	IPVersion  string  `json:"ip_version,omitempty"` // "4" or "6" when forced
+	Link       string  `json:"link,omitempty"`       // Named link used for the test
+	Proxy      string  `json:"proxy,omitempty"`      // Proxy name or redacted URL
+	Timestamp  int64   `json:"timestamp"`
+
+	AssumedLocation *AssumedLocation `json:"assumed_location,omitempty"`
+	Client          *ClientInfo      `json:"client,omitempty"` // Public IP/ISP of the server host
+}
+
+// TransferResult holds the fields shared by download and upload results
+type TransferResult struct {
+	TestID     string  `json:"test_id,omitempty"`
+	SpeedMbps  float64 `json:"speed_mbps"`
+	ServerID   string  `json:"server_id"`
+	Sponsor    string  `json:"sponsor"`
+	Location   string  `json:"location"`
+	ServerHost string  `json:"server_host"`
+	Country    string  `json:"country"`
+	Latency    float64 `json:"latency_ms"`
+	DurationMs int64   `json:"duration_ms"`
+	IPVersion  string  `json:"ip_version,omitempty"` // "4" or "6" when forced
+	Link       string  `json:"link,omitempty"`       // Named link used for the test
+	Proxy      string  `json:"proxy,omitempty"`      // Proxy name or redacted URL
+	Timestamp  int64   `json:"timestamp"`
+
+	AssumedLocation *AssumedLocation `json:"assumed_location,omitempty"`
+	Client          *ClientInfo      `json:"client,omitempty"` // Public IP/ISP of the server host
+}
+
+// DownloadResponse represents download test result
+type DownloadResponse struct {
+	TransferResult
+}
+
+// UploadResponse represents upload test result
+type UploadResponse struct {
+	TransferResult
+}
+
+// Transfer returns the result of a download or upload, or nil for
+// anything else (e.g. a PingResponse)
+func Transfer(result interface{}) (phase string, r *TransferResult) {
+	switch result := result.(type) {
+	case *DownloadResponse:
+		return "download", &result.TransferResult
+	case *UploadResponse:
+		return "upload", &result.TransferResult
+	}
+	return "", nil
+}
+
+// ServerInfo represents minimal server info for response
+type ServerInfo struct {
+	ID       string  `json:"id"`
+	Sponsor  string  `json:"sponsor"`
+	Location string  `json:"location"`
+	Host     string  `json:"host"`
+	Country  string  `json:"country"`
+	Distance float64 `json:"distance_km"`
+}
+
+// ServersResponse is the GET /speedtest/servers body
+type ServersResponse struct {
+	Count           int              `json:"count"`
+	Servers         []ServerInfo     `json:"servers"`
+	AssumedLocation *AssumedLocation `json:"assumed_location,omitempty"`
+}
+
+// Stream event types
+const (
+	EventStart    = "start"
+	EventProgress = "progress"
+	EventComplete = "complete"
+	EventError    = "error"
+	EventSummary  = "summary" // ip_version=both only, after both families
+)
+
+// StreamEvent represents SSE event for realtime progress
+type StreamEvent struct {
+	Type      string  `json:"type"`              // "start", "progress", "complete", "error", "summary"
+	TestID    string  `json:"test_id,omitempty"` // complete events; every event of /speedtest/live
+	Phase     string  `json:"phase,omitempty"`   // "download" or "upload" (/speedtest/live only)
+	SpeedMbps float64 `json:"speed_mbps"`
+	Elapsed   float64 `json:"elapsed_sec"`
+	ServerID  string  `json:"server_id,omitempty"`
+	Sponsor   string  `json:"sponsor,omitempty"`
+	Location  string  `json:"location,omitempty"`
+	Latency   float64 `json:"latency_ms,omitempty"`
+	Message   string  `json:"message,omitempty"`
+	IPVersion string  `json:"ip_version,omitempty"` // "4", "6", or "both" on summary
+	Link      string  `json:"link,omitempty"`
+	Proxy     string  `json:"proxy,omitempty"`
+
+	AssumedLocation *AssumedLocation        `json:"assumed_location,omitempty"` // start event only
+	Results         map[string]*StreamEvent `json:"results,omitempty"`          // summary event: "ipv4"/"ipv6" complete events
+}
+
+// AssumedLocation represents the client location used for server distances
+type AssumedLocation struct {
+	Name    string  `json:"name,omitempty"`    // City name from the embedded dataset
+	Country string  `json:"country,omitempty"` // ISO country code
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Source  string  `json:"source"` // "query" or "config"
+}
+
+// ClientInfo represents the public IP and ISP the test runs from
+type ClientInfo struct {
+	IP  string  `json:"ip"`
+	ISP string  `json:"isp"`
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Link is a named uplink: tests bind to its source IP or network interface
+type Link struct {
+	Name      string `json:"name"`
+	SourceIP  string `json:"source_ip,omitempty"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// LinksResponse is the GET /speedtest/links body
+type LinksResponse struct {
+	Count int     `json:"count"`
+	Links []*Link `json:"links"`
+}
+
+// StatusResponse is the GET / health check body
+type StatusResponse struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+	Library string `json:"library"`
+}
+
+// ErrorResponse for API errors
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
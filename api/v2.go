@@ -0,0 +1,78 @@
+package api
+
+// ==================== API v2 ====================
+
+// V2Prefix is the path prefix of the v2 API
+const V2Prefix = "/api/v2"
+
+// ProblemContentType is the media type of v2 error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Envelope wraps every successful v2 response
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
+// Meta describes a v2 response
+type Meta struct {
+	APIVersion string `json:"api_version"` // always "2"
+	Timestamp  int64  `json:"timestamp"`   // Unix ms when the response was written
+	DurationMs int64  `json:"duration_ms"` // Time spent handling the request
+}
+
+// Problem is an RFC 7807 problem details object. Code is a stable,
+// machine-readable error code (see the Code* constants); Type is derived from it.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Code + ": " + p.Detail
+	}
+	return p.Code + ": " + p.Title
+}
+
+// ProblemType returns the problem type URI for a code
+func ProblemType(code string) string {
+	return "urn:go-speedtest:problem:" + code
+}
+
+// Stable v2 error codes. New codes may be added; existing ones never change meaning.
+const (
+	CodeInvalidRequest       = "invalid_request"       // 400: bad query parameter
+	CodeInvalidServerID      = "invalid_server_id"     // 400: server_id is not a number
+	CodeServerNotFound       = "server_not_found"      // 404: server_id not in the catalogue
+	CodeNotFound             = "not_found"             // 404: unknown route
+	CodeMethodNotAllowed     = "method_not_allowed"    // 405
+	CodeCancelled            = "cancelled"             // 409: test cancelled by an admin
+	CodeCatalogueUnavailable = "catalogue_unavailable" // 502: speedtest.net server list fetch failed
+	CodePingFailed           = "ping_failed"           // 502
+	CodeDownloadFailed       = "download_failed"       // 502
+	CodeUploadFailed         = "upload_failed"         // 502
+	CodeWhoamiFailed         = "whoami_failed"         // 502
+	CodeProxyFailed          = "proxy_failed"          // 502: test through a proxy failed (details only in the server log)
+	CodeAllFamiliesFailed    = "all_families_failed"   // 502: ip_version=both, IPv4 and IPv6 failed
+	CodeNoServers            = "no_servers"            // 503: catalogue has no usable server
+	CodeTimeout              = "timeout"               // 504: test exceeded the request timeout
+	CodeInternal             = "internal_error"        // 500
+)
+
+// FamilyResult is one address family of a v2 dual-stack result: Data or Error
+type FamilyResult struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *Problem    `json:"error,omitempty"`
+}
+
+// DualStackData is the v2 data for ip_version=both
+type DualStackData struct {
+	IPVersion string        `json:"ip_version"` // always "both"
+	IPv4      *FamilyResult `json:"ipv4"`
+	IPv6      *FamilyResult `json:"ipv6"`
+}
@@ -0,0 +1,26 @@
+package api
+
+// ==================== WebSocket ====================
+
+// WebSocket command actions
+const (
+	ActionStart    = "start"    // start a test; one test at a time per connection
+	ActionCancel   = "cancel"   // cancel the running test
+	ActionInterval = "interval" // change the progress sample interval
+)
+
+// EventCancelled is sent over WebSocket when a test was cancelled by the client
+const EventCancelled = "cancelled"
+
+// WSCommand is a client message on the /speedtest/ws WebSocket.
+// The server answers with StreamEvent messages, the same events as the SSE streams.
+type WSCommand struct {
+	Action string `json:"action"` // "start", "cancel" or "interval"
+
+	// start only
+	Test    string            `json:"test,omitempty"`    // "download" or "upload"
+	Options map[string]string `json:"options,omitempty"` // Same keys as the stream query (server_id, duration, ip_version, ...)
+
+	// start and interval: minimum milliseconds between progress events (0 = every sample)
+	IntervalMs int `json:"interval_ms,omitempty"`
+}
@@ -14,17 +14,17 @@ mkdir -p $BUILD_DIR
 
 # Build for current platform
 echo "Building for current platform..."
-go build -ldflags="-s -w" -o $BUILD_DIR/$APP_NAME main.go
+go build -ldflags="-s -w" -o $BUILD_DIR/$APP_NAME .
 
 # Cross-compile for common platforms (optional)
 if [ "$1" == "--all" ]; then
     echo "Cross-compiling for multiple platforms..."
     
-    GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-linux-amd64 main.go
-    GOOS=linux GOARCH=arm64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-linux-arm64 main.go
-    GOOS=darwin GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-darwin-amd64 main.go
-    GOOS=darwin GOARCH=arm64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-darwin-arm64 main.go
-    GOOS=windows GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-windows-amd64.exe main.go
+    GOOS=linux GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-linux-amd64 .
+    GOOS=linux GOARCH=arm64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-linux-arm64 .
+    GOOS=darwin GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-darwin-amd64 .
+    GOOS=darwin GOARCH=arm64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-darwin-arm64 .
+    GOOS=windows GOARCH=amd64 go build -ldflags="-s -w" -o $BUILD_DIR/${APP_NAME}-windows-amd64.exe .
     
     echo "Cross-compile complete!"
 fi
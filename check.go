@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Nagios/Icinga Check ====================
+
+// Nagios plugin states (also the exit codes)
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+var checkStateNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkMetric is one measured value with its thresholds (negative = unset)
+type checkMetric struct {
+	label      string  // perfdata label
+	name       string  // human name
+	value      float64 // measured value
+	unit       string  // human unit
+	uom        string  // perfdata unit of measure
+	scale      float64 // perfdata value = value * scale
+	warn       float64
+	crit       float64
+	lowerIsBad bool // download/upload: alert when below threshold
+}
+
+// state evaluates the metric against its thresholds
+func (m *checkMetric) state() int {
+	breach := func(threshold float64) bool {
+		if threshold < 0 {
+			return false
+		}
+		if m.lowerIsBad {
+			return m.value < threshold
+		}
+		return m.value > threshold
+	}
+	switch {
+	case breach(m.crit):
+		return checkCritical
+	case breach(m.warn):
+		return checkWarning
+	default:
+		return checkOK
+	}
+}
+
+// perfdata formats the metric as Nagios perfdata ('label'=value[UOM];warn;crit;min;max)
+func (m *checkMetric) perfdata() string {
+	threshold := func(t float64) string {
+		if t < 0 {
+			return ""
+		}
+		// "N:" = alert when below N (Nagios range syntax)
+		if m.lowerIsBad {
+			return perfValue(t*m.scale) + ":"
+		}
+		return perfValue(t * m.scale)
+	}
+	max := ""
+	if m.uom == "%" {
+		max = "100"
+	}
+	return fmt.Sprintf("%s=%s%s;%s;%s;0;%s", m.label, perfValue(m.value*m.scale), m.uom,
+		threshold(m.warn), threshold(m.crit), max)
+}
+
+// perfValue formats a perfdata number in plain decimal notation; Nagios
+// rejects exponents like 1e+04. Rounded to 6 decimals to drop float noise
+// from scaling (12.3 ms = 0.0123 s).
+func perfValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+// cmdCheck - speedtest check [--warn-download 50 --crit-download 20 ...]
+// Runs a test locally, or via a running instance with --url, and exits with the Nagios state
+func cmdCheck(args []string) int {
+	flags := newTestFlags("check")
+	instanceURL := flags.fs.String("url", "", "Run the test through a running instance (e.g. http://127.0.0.1:8645)")
+	fromHistory := flags.fs.Bool("from-history", false, "Check the newest results in the --url instance's history instead of running a test")
+	maxAge := flags.fs.Duration("max-age", 0, "With --from-history: UNKNOWN if a result is older than this (0 = any age)")
+	timeout := flags.fs.Duration("timeout", 2*time.Minute, "Timeout for the whole check")
+	noDownload := flags.fs.Bool("no-download", false, "Skip download test")
+	noUpload := flags.fs.Bool("no-upload", false, "Skip upload test")
+	lossDuration := flags.fs.Duration("loss-duration", 10*time.Second, "Packet loss sampling duration (local mode)")
+	warnDownload := flags.fs.Float64("warn-download", -1, "Warning if download below Mbps")
+	critDownload := flags.fs.Float64("crit-download", -1, "Critical if download below Mbps")
+	warnUpload := flags.fs.Float64("warn-upload", -1, "Warning if upload below Mbps")
+	critUpload := flags.fs.Float64("crit-upload", -1, "Critical if upload below Mbps")
+	warnLatency := flags.fs.Float64("warn-latency", -1, "Warning if latency above ms")
+	critLatency := flags.fs.Float64("crit-latency", -1, "Critical if latency above ms")
+	warnLoss := flags.fs.Float64("warn-loss", -1, "Warning if packet loss above percent (enables loss test)")
+	critLoss := flags.fs.Float64("crit-loss", -1, "Critical if packet loss above percent (enables loss test)")
+
+	if err := flags.parseArgs(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return checkOK
+		}
+		return checkResult(checkUnknown, err.Error(), nil)
+	}
+	measureLoss := *warnLoss >= 0 || *critLoss >= 0
+
+	var result *checkRun
+	var err error
+	if *fromHistory {
+		if *instanceURL == "" {
+			return checkResult(checkUnknown, "--from-history requires --url", nil)
+		}
+		if measureLoss {
+			return checkResult(checkUnknown, "packet loss thresholds are not supported with --from-history", nil)
+		}
+		var opts *api.Options
+		opts, err = flags.apiOptions()
+		if err == nil {
+			result, err = historyCheckRun(*instanceURL, opts, *timeout, !*noDownload, !*noUpload, *maxAge)
+		}
+	} else if *instanceURL != "" {
+		if measureLoss {
+			return checkResult(checkUnknown, "packet loss thresholds are not supported with --url", nil)
+		}
+		var opts *api.Options
+		opts, err = flags.apiOptions()
+		if err == nil {
+			result, err = remoteCheckRun(*instanceURL, opts, *timeout, !*noDownload, !*noUpload)
+		}
+	} else {
+		var opts *testOptions
+		opts, err = flags.testOptions()
+		if err == nil {
+			result, err = localCheckRun(opts, *timeout, !*noDownload, !*noUpload, measureLoss, *lossDuration)
+		}
+	}
+	if err != nil {
+		return checkResult(checkUnknown, err.Error(), nil)
+	}
+
+	metrics := []*checkMetric{{
+		label: "latency", name: "Latency", value: result.latency, unit: "ms",
+		uom: "s", scale: 0.001, warn: *warnLatency, crit: *critLatency,
+	}}
+	if result.download != nil {
+		metrics = append(metrics, &checkMetric{
+			label: "download", name: "Download", value: *result.download, unit: "Mbps",
+			scale: 1, warn: *warnDownload, crit: *critDownload, lowerIsBad: true,
+		})
+	}
+	if result.upload != nil {
+		metrics = append(metrics, &checkMetric{
+			label: "upload", name: "Upload", value: *result.upload, unit: "Mbps",
+			scale: 1, warn: *warnUpload, crit: *critUpload, lowerIsBad: true,
+		})
+	}
+	if measureLoss {
+		if result.loss == nil {
+			return checkResult(checkUnknown, "packet loss not supported by server "+result.server, nil)
+		}
+		metrics = append(metrics, &checkMetric{
+			label: "packet_loss", name: "Packet loss", value: *result.loss, unit: "%",
+			uom: "%", scale: 1, warn: *warnLoss, crit: *critLoss,
+		})
+	}
+
+	state := checkOK
+	var parts []string
+	for _, m := range metrics {
+		if s := m.state(); s > state {
+			state = s
+		}
+		parts = append(parts, fmt.Sprintf("%s %.2f %s", m.name, m.value, m.unit))
+	}
+	return checkResult(state, fmt.Sprintf("%s (Server: %s)", strings.Join(parts, ", "), result.server), metrics)
+}
+
+// checkResult prints the plugin output line and returns the state as exit code
+func checkResult(state int, message string, metrics []*checkMetric) int {
+	fmt.Println(checkLine(state, message, metrics))
+	return state
+}
+
+// checkLine formats the plugin output: "SPEEDTEST STATE - message | perfdata"
+func checkLine(state int, message string, metrics []*checkMetric) string {
+	var perf []string
+	for _, m := range metrics {
+		perf = append(perf, m.perfdata())
+	}
+	line := fmt.Sprintf("SPEEDTEST %s - %s", checkStateNames[state], message)
+	if len(perf) > 0 {
+		line += " | " + strings.Join(perf, " ")
+	}
+	return line
+}
+
+// checkRun holds measured values; nil pointers were not measured
+type checkRun struct {
+	server   string
+	latency  float64
+	download *float64
+	upload   *float64
+	loss     *float64
+}
+
+// localCheckRun runs the test in-process with the same tester as the HTTP handlers
+func localCheckRun(opts *testOptions, timeout time.Duration, download, upload, loss bool, lossDuration time.Duration) (*checkRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	run, err := runLocalCheck(ctx, opts, download, upload, loss, lossDuration)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("check timed out after %s", timeout)
+	}
+	return run, err
+}
+
+// runLocalCheck measures latency, speeds and optionally packet loss
+func runLocalCheck(ctx context.Context, opts *testOptions, download, upload, loss bool, lossDuration time.Duration) (*checkRun, error) {
+	if loss && opts.Proxy != nil {
+		// Fail before the transfers rather than after them
+		return nil, errors.New("packet loss cannot be measured through a proxy")
+	}
+	server, err := speedTester.FindServer(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.Server = server
+	run := &checkRun{server: fmt.Sprintf("%s - %s [%s]", server.Sponsor, server.Name, server.ID)}
+
+	if !download && !upload {
+		ping, err := speedTester.Ping(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		run.latency = ping.Latency
+	}
+	if download || upload {
+		result, err := speedTester.Full(ctx, &tester.FullOptions{
+			Options:      *opts,
+			SkipDownload: !download,
+			SkipUpload:   !upload,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.Download != nil {
+			run.latency = result.Download.Latency
+			run.download = &result.Download.SpeedMbps
+		}
+		if result.Upload != nil {
+			run.latency = result.Upload.Latency
+			run.upload = &result.Upload.SpeedMbps
+		}
+	}
+	if loss {
+		value, ok, err := speedTester.PacketLoss(ctx, opts, lossDuration)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			run.loss = &value
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return run, nil
+}
+
+// remoteCheckRun asks a running instance to run the tests via its HTTP API
+func remoteCheckRun(baseURL string, opts *api.Options, timeout time.Duration, download, upload bool) (*checkRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := api.NewClient(baseURL)
+	run := &checkRun{}
+
+	if !download && !upload {
+		ping, err := client.Ping(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		run.server = fmt.Sprintf("%s - %s [%s]", ping.Sponsor, ping.Location, ping.ServerID)
+		run.latency = ping.Latency
+	}
+	if download {
+		result, err := client.Download(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		run.server = fmt.Sprintf("%s - %s [%s]", result.Sponsor, result.Location, result.ServerID)
+		run.latency = result.Latency
+		run.download = &result.SpeedMbps
+	}
+	if upload {
+		result, err := client.Upload(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		run.server = fmt.Sprintf("%s - %s [%s]", result.Sponsor, result.Location, result.ServerID)
+		run.latency = result.Latency
+		run.upload = &result.SpeedMbps
+	}
+	return run, nil
+}
+
+// historyCheckRun reads the newest results from a running instance's
+// history instead of running tests: the newest download and upload, or the
+// newest ping when both are skipped. Only results matching --server, --link,
+// --proxy and --ip-version count when those are set.
+func historyCheckRun(baseURL string, opts *api.Options, timeout time.Duration, download, upload bool, maxAge time.Duration) (*checkRun, error) {
+	if opts.City != "" || opts.Lat != nil || opts.Lon != nil {
+		return nil, errors.New("--city, --lat and --lon are not supported with --from-history")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	history, err := api.NewClient(baseURL).History(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []string
+	if !download && !upload {
+		types = append(types, tester.PhasePing)
+	}
+	if download {
+		types = append(types, tester.PhaseDownload)
+	}
+	if upload {
+		types = append(types, tester.PhaseUpload)
+	}
+
+	run := &checkRun{}
+	for _, testType := range types {
+		result := newestHistoryResult(history.Entries, testType, opts)
+		if result == nil {
+			return nil, fmt.Errorf("no %s result in history", testType)
+		}
+		age := time.Since(time.UnixMilli(result.timestamp))
+		if maxAge > 0 && age > maxAge {
+			return nil, fmt.Errorf("newest %s result is %s old (max %s)", testType, age.Round(time.Second), maxAge)
+		}
+
+		run.server = fmt.Sprintf("%s - %s [%s]", result.sponsor, result.location, result.serverID)
+		run.latency = result.latency
+		switch testType {
+		case tester.PhaseDownload:
+			run.download = &result.speed
+		case tester.PhaseUpload:
+			run.upload = &result.speed
+		}
+	}
+	return run, nil
+}
+
+// historyResult is the part of a history entry's result the check uses
+type historyResult struct {
+	serverID, sponsor, location string
+	link, proxy, ipVersion      string
+	latency, speed              float64
+	timestamp                   int64 // Unix ms
+}
+
+// newestHistoryResult returns the newest entry of testType matching opts
+// (entries are newest first), or nil
+func newestHistoryResult(entries []*api.HistoryEntry, testType string, opts *api.Options) *historyResult {
+	for _, entry := range entries {
+		if entry.Type != testType {
+			continue
+		}
+		var result *historyResult
+		if r, ok := entry.Result.(*api.PingResponse); ok {
+			result = &historyResult{r.ServerID, r.Sponsor, r.Location, r.Link, r.Proxy, r.IPVersion, r.Latency, 0, r.Timestamp}
+		} else if _, r := api.Transfer(entry.Result); r != nil {
+			result = &historyResult{r.ServerID, r.Sponsor, r.Location, r.Link, r.Proxy, r.IPVersion, r.Latency, r.SpeedMbps, r.Timestamp}
+		} else {
+			continue
+		}
+		if (opts.ServerID != "" && result.serverID != opts.ServerID) ||
+			(opts.Link != "" && result.link != opts.Link) ||
+			(opts.Proxy != "" && result.proxy != opts.Proxy) ||
+			(opts.IPVersion != "" && result.ipVersion != opts.IPVersion) {
+			continue
+		}
+		return result
+	}
+	return nil
+}
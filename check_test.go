@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-speedtest/api"
+)
+
+func TestCheckPerfdata(t *testing.T) {
+	for _, tc := range []struct {
+		metric checkMetric
+		want   string
+	}{
+		// 10 Gbps must not turn into 1e+04
+		{checkMetric{label: "download", value: 10000, scale: 1, warn: 5000, crit: 1000, lowerIsBad: true},
+			"download=10000;5000:;1000:;0;"},
+		{checkMetric{label: "download", value: 123456.789, scale: 1, warn: -1, crit: -1, lowerIsBad: true},
+			"download=123456.789;;;0;"},
+		{checkMetric{label: "latency", value: 12.3, uom: "s", scale: 0.001, warn: 50, crit: 100},
+			"latency=0.0123s;0.05;0.1;0;"},
+		{checkMetric{label: "packet_loss", value: 0.5, uom: "%", scale: 1, warn: 1, crit: -1},
+			"packet_loss=0.5%;1;;0;100"},
+	} {
+		if got := tc.metric.perfdata(); got != tc.want {
+			t.Errorf("%s %v: perfdata %q, want %q", tc.metric.label, tc.metric.value, got, tc.want)
+		}
+	}
+}
+
+func TestCheckMetricState(t *testing.T) {
+	download := checkMetric{value: 30, warn: 50, crit: 20, lowerIsBad: true}
+	latency := checkMetric{value: 30, warn: 20, crit: 50}
+	unset := checkMetric{value: 30, warn: -1, crit: -1}
+	for _, tc := range []struct {
+		metric checkMetric
+		value  float64
+		want   int
+	}{
+		{download, 60, checkOK},
+		{download, 30, checkWarning},
+		{download, 10, checkCritical},
+		{latency, 10, checkOK},
+		{latency, 30, checkWarning},
+		{latency, 60, checkCritical},
+		{unset, 1e9, checkOK},
+	} {
+		m := tc.metric
+		m.value = tc.value
+		if got := m.state(); got != tc.want {
+			t.Errorf("%+v: state %s, want %s", m, checkStateNames[got], checkStateNames[tc.want])
+		}
+	}
+}
+
+// captureStdout returns what fn printed
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func TestCheckFromHistoryOutput(t *testing.T) {
+	now := time.Now().UnixMilli()
+	transfer := api.TransferResult{
+		ServerID: "1234", Sponsor: "My ISP", Location: "Jakarta",
+		Latency: 12.3, Timestamp: now,
+	}
+	download, upload := transfer, transfer
+	download.SpeedMbps = 10000
+	upload.SpeedMbps = 950.5
+	history := map[string]interface{}{
+		"count": 2,
+		"entries": []map[string]interface{}{
+			{"id": "u1", "type": "upload", "timestamp": now, "result": &api.UploadResponse{TransferResult: upload}},
+			{"id": "d1", "type": "download", "timestamp": now, "result": &api.DownloadResponse{TransferResult: download}},
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/speedtest/history" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(history)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		args  []string
+		state int
+	}{
+		{[]string{"--warn-download", "5000", "--crit-download", "1000"}, checkOK},
+		{[]string{"--warn-upload", "1000"}, checkWarning},
+		{[]string{"--crit-latency", "10"}, checkCritical},
+	} {
+		var state int
+		out := captureStdout(t, func() {
+			state = cmdCheck(append([]string{"--url", srv.URL, "--from-history"}, tc.args...))
+		})
+		if state != tc.state {
+			t.Errorf("%v: exit %d, want %d (%s)", tc.args, state, tc.state, out)
+		}
+		status, perf, ok := strings.Cut(strings.TrimSuffix(out, "\n"), " | ")
+		if !ok || strings.Contains(out, "e+") {
+			t.Errorf("%v: bad plugin output %q", tc.args, out)
+			continue
+		}
+		wantStatus := "SPEEDTEST " + checkStateNames[tc.state] +
+			" - Latency 12.30 ms, Download 10000.00 Mbps, Upload 950.50 Mbps (Server: My ISP - Jakarta [1234])"
+		if status != wantStatus {
+			t.Errorf("%v: status %q, want %q", tc.args, status, wantStatus)
+		}
+		for _, label := range []string{"latency=0.0123s;", "download=10000;", "upload=950.5;"} {
+			if !strings.Contains(perf, label) {
+				t.Errorf("%v: perfdata %q missing %s", tc.args, perf, label)
+			}
+		}
+	}
+}
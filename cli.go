@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+
+	"github.com/chelnak/ysmrr"
+	"github.com/mattn/go-isatty"
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// ==================== CLI ====================
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1 // Test or network failure
+	exitUsage   = 2 // Invalid command or flags
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatText = "text"
+)
+
+const cliUsage = `Usage: speedtest [command] [flags]
+
+Commands:
+  serve     Run the HTTP API server (default)
+  run       Run download and upload test
+  ping      Run latency test
+  servers   List closest servers
+  check     Nagios/Icinga check with thresholds and perfdata
+  tui       Live terminal dashboard for a running instance
+
+Run 'speedtest <command> -h' for command flags.
+`
+
+// RunResult represents a CLI run (download + upload) result
+type RunResult = tester.FullResult
+
+// runCLI dispatches the subcommand and returns the process exit code
+func runCLI(args []string) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "serve":
+		if err := loadConfig(); err != nil {
+			fatal("config error", "error", err)
+		}
+		serve()
+		return exitOK
+	case "run":
+		return cmdRun(args)
+	case "ping":
+		return cmdPing(args)
+	case "servers":
+		return cmdServers(args)
+	case "check":
+		return cmdCheck(args)
+	case "tui":
+		return cmdTUI(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(cliUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, cliUsage)
+		return exitUsage
+	}
+}
+
+// cliFlags holds flags shared by the test subcommands
+type cliFlags struct {
+	fs *flag.FlagSet
+
+	server    string
+	city      string
+	lat       string
+	lon       string
+	ipVersion string
+	link      string
+	proxy     string
+	format    string
+	verbose   bool
+}
+
+// newCLIFlags registers the shared test flags on a new FlagSet
+func newCLIFlags(name string) *cliFlags {
+	f := newTestFlags(name)
+	f.fs.StringVar(&f.format, "format", FormatText, "Output format: json, csv or text")
+	return f
+}
+
+// newTestFlags registers test selection flags only (no output format)
+func newTestFlags(name string) *cliFlags {
+	f := &cliFlags{fs: flag.NewFlagSet(name, flag.ContinueOnError), format: FormatText}
+	f.fs.StringVar(&f.server, "server", "", "Speedtest server ID (default: closest)")
+	f.fs.StringVar(&f.city, "city", "", "Assumed client city (see cities.csv)")
+	f.fs.StringVar(&f.lat, "lat", "", "Assumed client latitude")
+	f.fs.StringVar(&f.lon, "lon", "", "Assumed client longitude")
+	f.fs.StringVar(&f.ipVersion, "ip-version", "", "Force address family: 4 or 6")
+	f.fs.StringVar(&f.link, "link", "", "Named link from SPEEDTEST_LINKS")
+	f.fs.StringVar(&f.proxy, "proxy", "", "Proxy name from SPEEDTEST_PROXIES or proxy URL")
+	f.fs.BoolVar(&f.verbose, "verbose", false, "Print server logs to stderr")
+	return f
+}
+
+// parse parses args and builds test options using the same rules as the HTTP API
+func (f *cliFlags) parse(args []string) (*testOptions, error) {
+	if err := f.parseArgs(args); err != nil {
+		return nil, err
+	}
+	return f.testOptions()
+}
+
+// parseArgs parses and validates flags without loading config
+func (f *cliFlags) parseArgs(args []string) error {
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+	if f.fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", f.fs.Arg(0))
+	}
+
+	switch f.format {
+	case FormatJSON, FormatCSV, FormatText:
+	default:
+		return fmt.Errorf("invalid format %q (use json, csv or text)", f.format)
+	}
+	if f.ipVersion == IPVersionBoth {
+		return fmt.Errorf("ip-version both is only supported by the HTTP API; run with 4 and 6 separately")
+	}
+
+	if !f.verbose {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+	}
+	return nil
+}
+
+// testOptions loads config and builds test options from the parsed flags
+func (f *cliFlags) testOptions() (*testOptions, error) {
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
+	// The CLI runs with the operator's own network access: --proxy may be a URL
+	allowProxyURLs = true
+	return newTestOptions(f.query())
+}
+
+// query returns the flags as HTTP API query parameters
+func (f *cliFlags) query() url.Values {
+	q := url.Values{}
+	for key, value := range map[string]string{
+		"server_id":  f.server,
+		"city":       f.city,
+		"lat":        f.lat,
+		"lon":        f.lon,
+		"ip_version": f.ipVersion,
+		"link":       f.link,
+		"proxy":      f.proxy,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	return q
+}
+
+// apiOptions returns the flags as options for the API client (remote mode)
+func (f *cliFlags) apiOptions() (*api.Options, error) {
+	opts := &api.Options{
+		ServerID:  f.server,
+		City:      f.city,
+		IPVersion: f.ipVersion,
+		Link:      f.link,
+		Proxy:     f.proxy,
+	}
+	for _, c := range []struct {
+		name  string
+		value string
+		dst   **float64
+	}{{"lat", f.lat, &opts.Lat}, {"lon", f.lon, &opts.Lon}} {
+		if c.value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(c.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", c.name, c.value)
+		}
+		*c.dst = &v
+	}
+	return opts, nil
+}
+
+// usageError prints a flag error and returns the usage exit code
+func usageError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitUsage
+}
+
+// failure prints a test error and returns the failure exit code
+func failure(p *progress, err error) int {
+	p.fail(err.Error())
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitFailure
+}
+
+// ==================== CLI Progress ====================
+
+// progress shows a ysmrr spinner on stderr; no-op when disabled
+type progress struct {
+	sm      ysmrr.SpinnerManager
+	spinner *ysmrr.Spinner
+}
+
+// newProgress starts a spinner when output is human and stderr is a terminal
+func newProgress(format, message string) *progress {
+	if format != FormatText || !isatty.IsTerminal(os.Stderr.Fd()) {
+		return &progress{}
+	}
+	sm := ysmrr.NewSpinnerManager(ysmrr.WithWriter(os.Stderr))
+	spinner := sm.AddSpinner(message)
+	sm.Start()
+	return &progress{sm: sm, spinner: spinner}
+}
+
+func (p *progress) update(format string, a ...interface{}) {
+	if p.spinner != nil {
+		p.spinner.UpdateMessagef(format, a...)
+	}
+}
+
+func (p *progress) done(message string) {
+	if p.spinner != nil {
+		p.spinner.CompleteWithMessage(message)
+		p.sm.Stop()
+		p.spinner = nil
+	}
+}
+
+func (p *progress) fail(message string) {
+	if p.spinner != nil {
+		p.spinner.ErrorWithMessage(message)
+		p.sm.Stop()
+		p.spinner = nil
+	}
+}
+
+// findServerWithProgress selects the test server, reporting on the spinner
+func findServerWithProgress(p *progress, opts *testOptions) (*speedtest.Server, error) {
+	p.update("Finding server...")
+	server, err := speedTester.FindServer(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	p.update("Testing %s (%s) - %s", server.Sponsor, server.Name, server.Country)
+	return server, nil
+}
+
+// ==================== CLI Commands ====================
+
+// cmdRun - speedtest run [--server 12345] [--format json|csv|text]
+func cmdRun(args []string) int {
+	flags := newCLIFlags("run")
+	noDownload := flags.fs.Bool("no-download", false, "Skip download test")
+	noUpload := flags.fs.Bool("no-upload", false, "Skip upload test")
+	opts, err := flags.parse(args)
+	if err != nil {
+		return usageError(err)
+	}
+	if *noDownload && *noUpload {
+		return usageError(fmt.Errorf("nothing to run: both -no-download and -no-upload set"))
+	}
+
+	p := newProgress(flags.format, "Starting...")
+	server, err := findServerWithProgress(p, opts)
+	if err != nil {
+		return failure(p, err)
+	}
+
+	opts.Server = server
+	result, err := speedTester.Full(context.Background(), &tester.FullOptions{
+		Options:      *opts,
+		SkipDownload: *noDownload,
+		SkipUpload:   *noUpload,
+		OnProgress: func(pr tester.Progress) {
+			if pr.Phase == tester.PhaseUpload {
+				p.update("Upload: %.2f Mbps", pr.SpeedMbps)
+			} else {
+				p.update("Download: %.2f Mbps", pr.SpeedMbps)
+			}
+		},
+	})
+	if err != nil {
+		return failure(p, err)
+	}
+	p.done("Done")
+
+	var rows []cliRow
+	if result.Download != nil {
+		d := result.Download
+		rows = append(rows, cliRow{"download", d.ServerID, d.Sponsor, d.Location, d.Country,
+			d.Latency, d.SpeedMbps, d.DurationMs, d.IPVersion, d.Link, d.Proxy, d.Timestamp})
+	}
+	if result.Upload != nil {
+		u := result.Upload
+		rows = append(rows, cliRow{"upload", u.ServerID, u.Sponsor, u.Location, u.Country,
+			u.Latency, u.SpeedMbps, u.DurationMs, u.IPVersion, u.Link, u.Proxy, u.Timestamp})
+	}
+	return writeResult(flags.format, result, rows)
+}
+
+// cmdPing - speedtest ping [--server 12345] [--format json|csv|text]
+func cmdPing(args []string) int {
+	flags := newCLIFlags("ping")
+	opts, err := flags.parse(args)
+	if err != nil {
+		return usageError(err)
+	}
+
+	p := newProgress(flags.format, "Starting...")
+	server, err := findServerWithProgress(p, opts)
+	if err != nil {
+		return failure(p, err)
+	}
+
+	opts.Server = server
+	result, err := speedTester.Ping(context.Background(), opts)
+	if err != nil {
+		return failure(p, err)
+	}
+	p.done("Done")
+
+	rows := []cliRow{{"ping", result.ServerID, result.Sponsor, result.Location, result.Country,
+		result.Latency, 0, 0, result.IPVersion, result.Link, result.Proxy, result.Timestamp}}
+	return writeResult(flags.format, result, rows)
+}
+
+// cmdServers - speedtest servers [--limit 10] [--format json|csv|text]
+func cmdServers(args []string) int {
+	flags := newCLIFlags("servers")
+	limit := flags.fs.Int("limit", 10, "Number of servers to list")
+	opts, err := flags.parse(args)
+	if err != nil {
+		return usageError(err)
+	}
+	if *limit < 1 {
+		return usageError(fmt.Errorf("invalid -limit %d (must be at least 1)", *limit))
+	}
+
+	p := newProgress(flags.format, "Fetching servers...")
+	servers, err := speedTester.Servers(context.Background(), opts)
+	if err != nil {
+		return failure(p, err)
+	}
+	p.done("Done")
+
+	serverList := tester.ServerInfoList(servers, *limit)
+
+	switch flags.format {
+	case FormatJSON:
+		return writeJSONOutput(api.ServersResponse{
+			Count:           len(serverList),
+			Servers:         serverList,
+			AssumedLocation: opts.Location,
+		})
+	case FormatCSV:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"id", "sponsor", "location", "country", "host", "distance_km"})
+		for _, s := range serverList {
+			w.Write([]string{s.ID, s.Sponsor, s.Location, s.Country, s.Host, formatFloat(s.Distance)})
+		}
+		w.Flush()
+		return exitOK
+	default:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSPONSOR\tLOCATION\tCOUNTRY\tDISTANCE")
+		for _, s := range serverList {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1f km\n", s.ID, s.Sponsor, s.Location, s.Country, s.Distance)
+		}
+		tw.Flush()
+		return exitOK
+	}
+}
+
+// ==================== CLI Output ====================
+
+// cliRow is one test result flattened for CSV and text output
+type cliRow struct {
+	Test       string
+	ServerID   string
+	Sponsor    string
+	Location   string
+	Country    string
+	Latency    float64
+	SpeedMbps  float64
+	DurationMs int64
+	IPVersion  string
+	Link       string
+	Proxy      string
+	Timestamp  int64
+}
+
+// writeResult writes result as JSON, or rows as CSV/text
+func writeResult(format string, result interface{}, rows []cliRow) int {
+	switch format {
+	case FormatJSON:
+		return writeJSONOutput(result)
+	case FormatCSV:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"test", "server_id", "sponsor", "location", "country", "latency_ms",
+			"speed_mbps", "duration_ms", "ip_version", "link", "proxy", "timestamp"})
+		for _, r := range rows {
+			w.Write([]string{r.Test, r.ServerID, r.Sponsor, r.Location, r.Country, formatFloat(r.Latency),
+				formatFloat(r.SpeedMbps), strconv.FormatInt(r.DurationMs, 10), r.IPVersion, r.Link, r.Proxy,
+				strconv.FormatInt(r.Timestamp, 10)})
+		}
+		w.Flush()
+	default:
+		if len(rows) > 0 {
+			r := rows[0]
+			fmt.Printf("Server:   %s (%s) - %s [%s]\n", r.Sponsor, r.Location, r.Country, r.ServerID)
+			fmt.Printf("Latency:  %.2f ms\n", r.Latency)
+			if r.Link != "" {
+				fmt.Printf("Link:     %s\n", r.Link)
+			}
+			if r.Proxy != "" {
+				fmt.Printf("Proxy:    %s\n", r.Proxy)
+			}
+		}
+		for _, r := range rows {
+			switch r.Test {
+			case "download":
+				fmt.Printf("Download: %.2f Mbps (%.1fs)\n", r.SpeedMbps, float64(r.DurationMs)/1000)
+			case "upload":
+				fmt.Printf("Upload:   %.2f Mbps (%.1fs)\n", r.SpeedMbps, float64(r.DurationMs)/1000)
+			}
+		}
+	}
+	return exitOK
+}
+
+// writeJSONOutput writes indented JSON to stdout
+func writeJSONOutput(data interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// formatFloat formats a float for CSV output
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+
+	"go-speedtest/tester"
+)
+
+// ==================== Dual-Stack Testing ====================
+
+// ip_version query values
+const (
+	IPVersion4    = tester.IPVersion4
+	IPVersion6    = tester.IPVersion6
+	IPVersionBoth = tester.IPVersionBoth
+)
+
+// DualStackResult represents side-by-side IPv4 and IPv6 results (see tester.DualStack)
+type DualStackResult = tester.DualStackResult
+
+// writeDualStack writes a dual-stack result (503 when both families failed)
+func writeDualStack(w http.ResponseWriter, result *DualStackResult) {
+	if result.AllFailed() {
+		writeJSON(w, http.StatusServiceUnavailable, result)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Exporters ====================
+//
+// Exporters push completed results (and optionally progress samples) to
+// time series databases: InfluxDB line protocol over HTTP or UDP
+// (influx.go) and Prometheus remote-write (remotewrite.go). Points are
+// queued, written in batches and retried with backoff.
+
+// metricPoint is one measurement: tags identify the test, fields hold the values
+type metricPoint struct {
+	measurement string // "speedtest" or "speedtest_progress"
+	tags        map[string]string
+	fields      map[string]float64
+	time        time.Time
+}
+
+// exportSink writes a batch of points to one backend
+type exportSink interface {
+	Name() string
+	Write(ctx context.Context, points []metricPoint) error
+}
+
+// permanentError marks a write failure that retrying cannot fix (e.g. HTTP 400)
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// exportRetryWait is the backoff before the first retry, doubled after each one
+var exportRetryWait = time.Second
+
+// exportConfig holds batching settings shared by all sinks
+type exportConfig struct {
+	BatchSize     int           // EXPORT_BATCH_SIZE
+	FlushInterval time.Duration // EXPORT_FLUSH_INTERVAL
+	MaxRetries    int           // EXPORT_MAX_RETRIES
+	Samples       bool          // EXPORT_SAMPLES: also export progress samples
+}
+
+// loadExporters builds the configured sinks; nil when none is configured
+func loadExporters() (*exportConfig, []exportSink, error) {
+	var sinks []exportSink
+
+	if writeURL := os.Getenv("INFLUX_URL"); writeURL != "" {
+		sink, err := newInfluxHTTPSink(writeURL, os.Getenv("INFLUX_TOKEN"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid INFLUX_URL: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if addr := os.Getenv("INFLUX_UDP"); addr != "" {
+		sink, err := newInfluxUDPSink(addr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid INFLUX_UDP: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if writeURL := os.Getenv("REMOTE_WRITE_URL"); writeURL != "" {
+		sink, err := newRemoteWriteSink(writeURL, os.Getenv("REMOTE_WRITE_USERNAME"),
+			os.Getenv("REMOTE_WRITE_PASSWORD"), os.Getenv("REMOTE_WRITE_BEARER_TOKEN"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid REMOTE_WRITE_URL: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, nil, nil
+	}
+
+	cfg := &exportConfig{BatchSize: 100, FlushInterval: 10 * time.Second, MaxRetries: 3}
+	if v := os.Getenv("EXPORT_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, nil, fmt.Errorf("invalid EXPORT_BATCH_SIZE %q", v)
+		}
+		cfg.BatchSize = n
+	}
+	if v := os.Getenv("EXPORT_FLUSH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("invalid EXPORT_FLUSH_INTERVAL %q (e.g. 10s)", v)
+		}
+		cfg.FlushInterval = d
+	}
+	if v := os.Getenv("EXPORT_MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, nil, fmt.Errorf("invalid EXPORT_MAX_RETRIES %q", v)
+		}
+		cfg.MaxRetries = n
+	}
+	cfg.Samples = os.Getenv("EXPORT_SAMPLES") == "true"
+	return cfg, sinks, nil
+}
+
+// exporter batches points for one sink
+type exporter struct {
+	cfg   *exportConfig
+	sink  exportSink
+	queue chan metricPoint
+}
+
+// startExporters hooks every sink into speedTester results (and samples)
+func startExporters(cfg *exportConfig, sinks []exportSink) {
+	for _, sink := range sinks {
+		e := &exporter{cfg: cfg, sink: sink, queue: make(chan metricPoint, cfg.BatchSize*10)}
+		go e.run()
+
+		speedTester.OnResult(func(result interface{}) {
+			if p, ok := resultPoint(result); ok {
+				e.enqueue(p)
+			}
+		})
+		if cfg.Samples {
+			speedTester.OnSample(func(s tester.Sample) { e.enqueue(samplePoint(s)) })
+		}
+		slog.Info("exporter started", "sink", sink.Name(), "batch_size", cfg.BatchSize,
+			"flush_interval", cfg.FlushInterval, "samples", cfg.Samples)
+	}
+}
+
+// enqueue adds p without blocking the test
+func (e *exporter) enqueue(p metricPoint) {
+	select {
+	case e.queue <- p:
+	default:
+		slog.Warn("exporter queue full, dropping point", "sink", e.sink.Name())
+	}
+}
+
+// run flushes when the batch is full or every FlushInterval, until the
+// queue is closed
+func (e *exporter) run() {
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []metricPoint
+	for {
+		select {
+		case p, ok := <-e.queue:
+			if !ok {
+				if len(batch) > 0 {
+					e.flush(batch)
+				}
+				return
+			}
+			batch = append(batch, p)
+			if len(batch) < e.cfg.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		e.flush(batch)
+		batch = nil
+	}
+}
+
+// flush writes batch, retrying transient failures with exponential backoff
+func (e *exporter) flush(batch []metricPoint) {
+	wait := exportRetryWait
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := e.sink.Write(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= e.cfg.MaxRetries {
+			slog.Error("exporter dropping points", "sink", e.sink.Name(), "points", len(batch), "error", err)
+			return
+		}
+		slog.Warn("exporter write failed", "sink", e.sink.Name(), "retry_in", wait, "error", err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// retryableStatus reports whether an HTTP status is worth retrying
+func retryableStatus(status int) bool {
+	return status == 429 || status >= 500
+}
+
+// ==================== Points ====================
+
+// resultTags are the tags shared by results and samples
+func resultTags(test, serverID, sponsor, location, country, ipVersion, link, proxy string) map[string]string {
+	tags := map[string]string{
+		"test":       test,
+		"server_id":  serverID,
+		"sponsor":    sponsor,
+		"location":   location,
+		"country":    country,
+		"ip_version": ipVersion,
+		"link":       link,
+		"proxy":      proxy,
+	}
+	for k, v := range tags {
+		if v == "" {
+			delete(tags, k)
+		}
+	}
+	return tags
+}
+
+// resultPoint converts a tester result to a "speedtest" point
+func resultPoint(result interface{}) (metricPoint, bool) {
+	if r, ok := result.(*api.PingResponse); ok {
+		return metricPoint{
+			measurement: "speedtest",
+			tags:        resultTags(tester.PhasePing, r.ServerID, r.Sponsor, r.Location, r.Country, r.IPVersion, r.Link, r.Proxy),
+			fields:      map[string]float64{"latency_ms": r.Latency, "distance_km": r.Distance},
+			time:        time.UnixMilli(r.Timestamp),
+		}, true
+	}
+	if phase, r := api.Transfer(result); r != nil {
+		return metricPoint{
+			measurement: "speedtest",
+			tags:        resultTags(phase, r.ServerID, r.Sponsor, r.Location, r.Country, r.IPVersion, r.Link, r.Proxy),
+			fields: map[string]float64{
+				"speed_mbps":  r.SpeedMbps,
+				"latency_ms":  r.Latency,
+				"duration_ms": float64(r.DurationMs),
+			},
+			time: time.UnixMilli(r.Timestamp),
+		}, true
+	}
+	return metricPoint{}, false
+}
+
+// samplePoint converts a progress sample to a "speedtest_progress" point
+func samplePoint(s tester.Sample) metricPoint {
+	return metricPoint{
+		measurement: "speedtest_progress",
+		tags:        resultTags(s.Phase, s.ServerID, s.Sponsor, "", "", s.IPVersion, s.Link, s.Proxy),
+		fields: map[string]float64{
+			"speed_mbps":  s.SpeedMbps,
+			"elapsed_sec": s.Elapsed.Seconds(),
+		},
+		time: s.Time,
+	}
+}
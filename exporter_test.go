@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPoint returns a result point for test, at second n of 2024-01-01
+func testPoint(test string, n int) metricPoint {
+	return metricPoint{
+		measurement: "speedtest",
+		tags:        resultTags(test, "1234", "My ISP", "Jakarta", "ID", "", "", ""),
+		fields:      map[string]float64{"speed_mbps": float64(n)},
+		time:        time.Date(2024, 1, 1, 0, 0, n, 0, time.UTC),
+	}
+}
+
+// writeServer is an InfluxDB write endpoint answering with statuses in
+// order (then 204) and recording the lines of every request
+type writeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests [][]string
+	received chan struct{}
+}
+
+func newWriteServer(t *testing.T, statuses ...int) *writeServer {
+	s := &writeServer{statuses: statuses, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+		status := http.StatusNoContent
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+		s.received <- struct{}{}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// batches returns the number of lines of each request so far
+func (s *writeServer) batches() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sizes []int
+	for _, lines := range s.requests {
+		sizes = append(sizes, len(lines))
+	}
+	return sizes
+}
+
+// wait blocks until n more requests arrived
+func (s *writeServer) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for write request (got %v)", s.batches())
+		}
+	}
+}
+
+// testExporter returns an exporter writing to srv through the InfluxDB HTTP sink
+func testExporter(t *testing.T, srv *writeServer, cfg exportConfig) *exporter {
+	sink, err := newInfluxHTTPSink(srv.URL+"/write?db=net", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &exporter{cfg: &cfg, sink: sink, queue: make(chan metricPoint, 100)}
+}
+
+// fastRetries shortens the flush backoff for the test
+func fastRetries(t *testing.T) {
+	wait := exportRetryWait
+	exportRetryWait = time.Millisecond
+	t.Cleanup(func() { exportRetryWait = wait })
+}
+
+func TestExporterFlushesFullBatch(t *testing.T) {
+	srv := newWriteServer(t)
+	e := testExporter(t, srv, exportConfig{BatchSize: 3, FlushInterval: time.Hour})
+	done := make(chan struct{})
+	go func() { e.run(); close(done) }()
+
+	for i := 1; i <= 7; i++ {
+		e.enqueue(testPoint("download", i))
+	}
+	srv.wait(t, 2)
+	if got := srv.batches(); len(got) != 2 || got[0] != 3 || got[1] != 3 {
+		t.Errorf("batches %v, want [3 3] before the flush interval", got)
+	}
+
+	// Closing the queue flushes the partial batch
+	close(e.queue)
+	<-done
+	if got := srv.batches(); len(got) != 3 || got[2] != 1 {
+		t.Errorf("batches %v, want [3 3 1] after close", got)
+	}
+}
+
+func TestExporterFlushesOnInterval(t *testing.T) {
+	srv := newWriteServer(t)
+	e := testExporter(t, srv, exportConfig{BatchSize: 100, FlushInterval: 50 * time.Millisecond})
+	go e.run()
+	defer close(e.queue)
+
+	start := time.Now()
+	e.enqueue(testPoint("ping", 1))
+	e.enqueue(testPoint("ping", 2))
+	srv.wait(t, 1)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("partial batch flushed after %s, want about the 50ms interval", elapsed)
+	}
+	if got := srv.batches(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("batches %v, want [2]", got)
+	}
+
+	// An idle tick writes nothing
+	time.Sleep(150 * time.Millisecond)
+	if got := srv.batches(); len(got) != 1 {
+		t.Errorf("batches %v, want no write for an empty batch", got)
+	}
+}
+
+func TestExporterRetriesTransientErrors(t *testing.T) {
+	fastRetries(t)
+	srv := newWriteServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusInternalServerError)
+	e := testExporter(t, srv, exportConfig{BatchSize: 2, FlushInterval: time.Hour, MaxRetries: 3})
+
+	batch := []metricPoint{testPoint("download", 1), testPoint("upload", 2)}
+	e.flush(batch)
+	if got := srv.batches(); len(got) != 4 {
+		t.Fatalf("%d write attempts, want 4 (3 failures then success)", len(got))
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for i, lines := range srv.requests {
+		if strings.Join(lines, "\n") != lineProtocol(batch[0])+"\n"+lineProtocol(batch[1]) {
+			t.Errorf("attempt %d sent a different batch: %v", i+1, lines)
+		}
+	}
+}
+
+func TestExporterGivesUpAfterMaxRetries(t *testing.T) {
+	fastRetries(t)
+	srv := newWriteServer(t, 500, 500, 500, 500, 500)
+	e := testExporter(t, srv, exportConfig{BatchSize: 1, FlushInterval: time.Hour, MaxRetries: 2})
+
+	e.flush([]metricPoint{testPoint("ping", 1)})
+	if got := srv.batches(); len(got) != 3 {
+		t.Errorf("%d write attempts, want 3 (MaxRetries 2)", len(got))
+	}
+}
+
+func TestExporterDropsPermanentErrors(t *testing.T) {
+	fastRetries(t)
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		srv := newWriteServer(t, status)
+		e := testExporter(t, srv, exportConfig{BatchSize: 1, FlushInterval: time.Hour, MaxRetries: 3})
+
+		e.flush([]metricPoint{testPoint("ping", 1)})
+		if got := srv.batches(); len(got) != 1 {
+			t.Errorf("HTTP %d: %d write attempts, want 1 (not retried)", status, len(got))
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/speedtestpb"
+	"go-speedtest/tester"
+
+	"github.com/showwin/speedtest-go/speedtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// ==================== gRPC Service ====================
+
+// grpcServer implements speedtestpb.SpeedtestServer on top of speedTester
+type grpcServer struct {
+	speedtestpb.UnimplementedSpeedtestServer
+}
+
+// serveGRPC runs the gRPC service on port (GRPC_PORT) next to the HTTP server
+func serveGRPC(port string) {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		fatal("gRPC listen failed", "error", err)
+	}
+
+	s := grpc.NewServer()
+	speedtestpb.RegisterSpeedtestServer(s, &grpcServer{})
+	reflection.Register(s) // grpcurl / grpcui tanpa file .proto
+
+	slog.Info("starting gRPC server", "port", port)
+	if err := s.Serve(lis); err != nil {
+		fatal("gRPC server failed", "error", err)
+	}
+}
+
+// grpcTestOptions validates request options the same way as the HTTP query
+func grpcTestOptions(o *speedtestpb.TestOptions) (*testOptions, error) {
+	if o == nil {
+		o = &speedtestpb.TestOptions{}
+	}
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("server_id", o.GetServerId())
+	set("city", o.GetCity())
+	set("ip_version", o.GetIpVersion())
+	set("link", o.GetLink())
+	set("proxy", o.GetProxy())
+	if o.Lat != nil {
+		q.Set("lat", strconv.FormatFloat(o.GetLat(), 'f', -1, 64))
+	}
+	if o.Lon != nil {
+		q.Set("lon", strconv.FormatFloat(o.GetLon(), 'f', -1, 64))
+	}
+
+	if q.Get("ip_version") == IPVersionBoth {
+		return nil, status.Error(codes.InvalidArgument, "ip_version=both is not supported over gRPC; call once per family")
+	}
+	opts, err := newTestOptions(q)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return opts, nil
+}
+
+// grpcError converts a test error to a gRPC status, using the same mapping as API v2
+func grpcError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	httpStatus, _ := problemStatus(err)
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusConflict:
+		code = codes.Aborted
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
+
+// grpcContext tags tests run with ctx with the gRPC peer address (see /admin/tests)
+// and the x-request-id metadata (or a new ID) for log lines
+func grpcContext(ctx context.Context) context.Context {
+	requester := "grpc"
+	if p, ok := peer.FromContext(ctx); ok {
+		requester += " " + p.Addr.String()
+	}
+
+	id := newRequestID()
+	if ids := metadata.ValueFromIncomingContext(ctx, "x-request-id"); len(ids) > 0 && validRequestID(ids[0]) {
+		id = ids[0]
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+	return withRequestID(tester.WithRequester(ctx, requester), id)
+}
+
+// Ping measures latency to the selected server
+func (g *grpcServer) Ping(ctx context.Context, req *speedtestpb.TestOptions) (*speedtestpb.PingResult, error) {
+	ctx = grpcContext(ctx)
+	opts, err := grpcTestOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "grpc: starting ping test")
+	r, err := speedTester.Ping(ctx, opts)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &speedtestpb.PingResult{
+		LatencyMs: r.Latency,
+		Server: &speedtestpb.Server{
+			Id: r.ServerID, Sponsor: r.Sponsor, Location: r.Location,
+			Host: r.ServerHost, Country: r.Country, DistanceKm: r.Distance,
+		},
+		IpVersion:       r.IPVersion,
+		Link:            r.Link,
+		Proxy:           r.Proxy,
+		TimestampMs:     r.Timestamp,
+		AssumedLocation: pbAssumedLocation(r.AssumedLocation),
+		Client:          pbClientInfo(r.Client),
+	}, nil
+}
+
+// Download streams a download test
+func (g *grpcServer) Download(req *speedtestpb.TransferRequest, stream grpc.ServerStreamingServer[speedtestpb.TransferEvent]) error {
+	return g.runPhases(req, stream, tester.PhaseDownload)
+}
+
+// Upload streams an upload test
+func (g *grpcServer) Upload(req *speedtestpb.TransferRequest, stream grpc.ServerStreamingServer[speedtestpb.TransferEvent]) error {
+	return g.runPhases(req, stream, tester.PhaseUpload)
+}
+
+// Full streams download then upload against one server
+func (g *grpcServer) Full(req *speedtestpb.TransferRequest, stream grpc.ServerStreamingServer[speedtestpb.TransferEvent]) error {
+	return g.runPhases(req, stream, tester.PhaseDownload, tester.PhaseUpload)
+}
+
+// runPhases selects the server once and streams each phase
+func (g *grpcServer) runPhases(req *speedtestpb.TransferRequest,
+	stream grpc.ServerStreamingServer[speedtestpb.TransferEvent], phases ...string) error {
+	ctx := grpcContext(stream.Context())
+	opts, err := grpcTestOptions(req.GetOptions())
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "grpc: starting test", "phases", phases)
+	server, err := speedTester.FindServer(ctx, opts)
+	if err != nil {
+		slog.WarnContext(ctx, "grpc: server selection failed", "error", err)
+		return grpcError(err)
+	}
+	opts.Server = server
+
+	for _, phase := range phases {
+		if err := g.transfer(ctx, stream, opts, grpcDuration(req.GetDurationSeconds()), phase); err != nil {
+			return grpcError(err)
+		}
+	}
+	return nil
+}
+
+// grpcDuration converts duration_seconds, capped like the SSE duration param
+// (0 = run to completion)
+func grpcDuration(seconds uint32) time.Duration {
+	if seconds > maxStreamDuration {
+		seconds = maxStreamDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// transfer runs one phase, sending start, progress and result events
+func (g *grpcServer) transfer(ctx context.Context, stream grpc.ServerStreamingServer[speedtestpb.TransferEvent],
+	opts *testOptions, duration time.Duration, phase string) error {
+	transferOpts := &tester.TransferOptions{
+		Options:  *opts,
+		Duration: duration,
+		OnStart: func(server *speedtest.Server, latency float64) {
+			stream.Send(&speedtestpb.TransferEvent{Event: &speedtestpb.TransferEvent_Start{
+				Start: &speedtestpb.TransferStart{Phase: phase, Server: pbServer(server), LatencyMs: latency},
+			}})
+		},
+		OnProgress: func(p tester.Progress) {
+			stream.Send(&speedtestpb.TransferEvent{Event: &speedtestpb.TransferEvent_Progress{
+				Progress: &speedtestpb.Progress{Phase: p.Phase, SpeedMbps: p.SpeedMbps, ElapsedSeconds: p.Elapsed.Seconds()},
+			}})
+		},
+	}
+
+	var response interface{}
+	var err error
+	switch phase {
+	case tester.PhaseDownload:
+		response, err = speedTester.Download(ctx, transferOpts)
+	case tester.PhaseUpload:
+		response, err = speedTester.Upload(ctx, transferOpts)
+	default:
+		return fmt.Errorf("unknown phase %q", phase)
+	}
+	if err != nil {
+		return err
+	}
+	_, r := api.Transfer(response)
+
+	result := &speedtestpb.TransferResult{
+		Phase:     phase,
+		SpeedMbps: r.SpeedMbps,
+		Server: &speedtestpb.Server{
+			Id: r.ServerID, Sponsor: r.Sponsor, Location: r.Location,
+			Host: r.ServerHost, Country: r.Country, DistanceKm: opts.Server.Distance,
+		},
+		LatencyMs:       r.Latency,
+		DurationMs:      r.DurationMs,
+		IpVersion:       r.IPVersion,
+		Link:            r.Link,
+		Proxy:           r.Proxy,
+		TimestampMs:     r.Timestamp,
+		AssumedLocation: pbAssumedLocation(r.AssumedLocation),
+		Client:          pbClientInfo(r.Client),
+	}
+	return stream.Send(&speedtestpb.TransferEvent{Event: &speedtestpb.TransferEvent_Result{Result: result}})
+}
+
+// ListServers returns the closest servers
+func (g *grpcServer) ListServers(ctx context.Context, req *speedtestpb.ListServersRequest) (*speedtestpb.ListServersResponse, error) {
+	ctx = grpcContext(ctx)
+	opts, err := grpcTestOptions(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := speedTester.Servers(ctx, opts)
+	if err != nil {
+		slog.WarnContext(ctx, "grpc: server list failed", "error", err)
+		return nil, grpcError(err)
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = 10
+	}
+	response := &speedtestpb.ListServersResponse{AssumedLocation: pbAssumedLocation(opts.Location)}
+	for _, s := range tester.ServerInfoList(servers, limit) {
+		response.Servers = append(response.Servers, &speedtestpb.Server{
+			Id: s.ID, Sponsor: s.Sponsor, Location: s.Location,
+			Host: s.Host, Country: s.Country, DistanceKm: s.Distance,
+		})
+	}
+	return response, nil
+}
+
+// ==================== Conversions ====================
+
+func pbServer(s *speedtest.Server) *speedtestpb.Server {
+	return &speedtestpb.Server{
+		Id: s.ID, Sponsor: s.Sponsor, Location: s.Name,
+		Host: s.Host, Country: s.Country, DistanceKm: s.Distance,
+	}
+}
+
+func pbAssumedLocation(l *api.AssumedLocation) *speedtestpb.AssumedLocation {
+	if l == nil {
+		return nil
+	}
+	return &speedtestpb.AssumedLocation{Name: l.Name, Country: l.Country, Lat: l.Lat, Lon: l.Lon, Source: l.Source}
+}
+
+func pbClientInfo(c *api.ClientInfo) *speedtestpb.ClientInfo {
+	if c == nil {
+		return nil
+	}
+	return &speedtestpb.ClientInfo{Ip: c.IP, Isp: c.ISP, Lat: c.Lat, Lon: c.Lon}
+}
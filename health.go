@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Health & Readiness ====================
+//
+// /healthz only says the process is serving requests (liveness probe).
+// /readyz checks what a test needs (readiness probe): a fresh server
+// catalogue and writable history storage. It only reads cached state; the
+// catalogue is kept fresh by refreshCatalogueLoop in the background, so
+// probes never wait on Ookla.
+
+const (
+	// defaultCatalogueMaxAge is how old the catalogue may get before /readyz fails
+	defaultCatalogueMaxAge = 15 * time.Minute
+	// catalogueRefreshInterval is how often the background loop looks at the
+	// catalogue age; it also spaces out refetches after a failed one, so the
+	// loop does not hammer Ookla while it is unreachable
+	catalogueRefreshInterval = 30 * time.Second
+	// catalogueFetchTimeout bounds one background refetch
+	catalogueFetchTimeout = 10 * time.Second
+)
+
+// startTime is when the process started (uptime in /healthz)
+var startTime = time.Now()
+
+// catalogueMaxAge is READY_CATALOGUE_MAX_AGE, set up by serve
+var catalogueMaxAge = defaultCatalogueMaxAge
+
+// loadCatalogueMaxAge reads READY_CATALOGUE_MAX_AGE (default 15m)
+func loadCatalogueMaxAge() (time.Duration, error) {
+	v := os.Getenv("READY_CATALOGUE_MAX_AGE")
+	if v == "" {
+		return defaultCatalogueMaxAge, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid READY_CATALOGUE_MAX_AGE %q (e.g. 15m)", v)
+	}
+	return d, nil
+}
+
+// healthzHandler - GET /healthz
+// Liveness: 200 as long as the process serves requests
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, api.HealthResponse{
+		Status:    "alive",
+		Version:   Version,
+		UptimeSec: time.Since(startTime).Seconds(),
+	})
+}
+
+// readyzHandler - GET /readyz
+// Readiness: 200 when every check passes, 503 otherwise (same body)
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	response := api.ReadyResponse{
+		Status: "ready",
+		Checks: api.ReadyChecks{
+			Catalogue:   checkCatalogue(),
+			Storage:     checkStorage(),
+			Coordinator: checkCoordinator(),
+		},
+	}
+	status := http.StatusOK
+	for _, s := range []string{response.Checks.Catalogue.Status, response.Checks.Storage.Status, response.Checks.Coordinator.Status} {
+		if s != api.CheckOK {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if status != http.StatusOK {
+		slog.DebugContext(r.Context(), "not ready", "catalogue", response.Checks.Catalogue.Message,
+			"storage", response.Checks.Storage.Message)
+	}
+	writeJSON(w, status, response)
+}
+
+// checkCatalogue reports the cached catalogue status (see refreshCatalogueLoop)
+func checkCatalogue() api.CatalogueCheck {
+	status := speedTester.Catalogue()
+	check := api.CatalogueCheck{
+		Status:    api.CheckOK,
+		Servers:   status.Servers,
+		MaxAgeSec: catalogueMaxAge.Seconds(),
+	}
+	if !status.LastSuccess.IsZero() {
+		check.LastFetch = status.LastSuccess.UnixMilli()
+		check.AgeSec = time.Since(status.LastSuccess).Seconds()
+	}
+	if !status.LastAttempt.IsZero() {
+		check.LastAttempt = status.LastAttempt.UnixMilli()
+	}
+	if status.LastError != nil {
+		check.LastError = status.LastError.Error()
+	}
+
+	switch {
+	case status.LastSuccess.IsZero() && status.LastError != nil:
+		check.Status, check.Message = api.CheckFail, "server catalogue could not be fetched"
+	case status.LastSuccess.IsZero():
+		check.Status, check.Message = api.CheckFail, "server catalogue has not been fetched"
+	case time.Since(status.LastSuccess) > catalogueMaxAge:
+		check.Status, check.Message = api.CheckFail, "server catalogue is stale"
+	case status.Servers == 0:
+		check.Status, check.Message = api.CheckFail, "server catalogue is empty"
+	}
+	return check
+}
+
+// refreshCatalogueLoop keeps the catalogue fresh for /readyz: it fetches it
+// at startup, then whenever it is older than half of catalogueMaxAge, so a
+// failed refetch is retried before readiness flips
+func refreshCatalogueLoop(ctx context.Context) {
+	ticker := time.NewTicker(catalogueRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if catalogueDue(speedTester.Catalogue()) {
+			refreshCatalogue(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// catalogueDue reports whether refreshCatalogueLoop should refetch the catalogue
+func catalogueDue(status tester.CatalogueStatus) bool {
+	return status.LastSuccess.IsZero() || time.Since(status.LastSuccess) > catalogueMaxAge/2
+}
+
+// refreshCatalogue fetches the catalogue with the default options; the
+// result is recorded by the tester (see tester.Catalogue)
+func refreshCatalogue(ctx context.Context) {
+	opts, err := newTestOptions(url.Values{})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, catalogueFetchTimeout)
+	defer cancel()
+	if _, err := speedTester.Servers(ctx, opts); err != nil {
+		slog.WarnContext(ctx, "readiness: catalogue fetch failed", "error", err)
+	}
+}
+
+// checkStorage probes the history file (memory-only history is always ok)
+func checkStorage() api.StorageCheck {
+	check := api.StorageCheck{
+		Status:  api.CheckOK,
+		Backend: "memory",
+		Entries: history.len(),
+	}
+	if history.path == "" {
+		return check
+	}
+	check.Backend, check.Path = "file", history.path
+	if err := history.Err(); err != nil {
+		check.LastError = err.Error()
+	}
+	if err := history.check(); err != nil {
+		check.Status, check.Message = api.CheckFail, "history file is not writable: "+err.Error()
+	}
+	return check
+}
+
+// checkCoordinator reports test slot usage. It never fails: queued tests
+// still run once a MAX_CONCURRENT_TESTS slot frees up, and taking the
+// instance out of rotation would only move the load elsewhere.
+func checkCoordinator() api.CoordinatorCheck {
+	check := api.CoordinatorCheck{
+		Status:        api.CheckOK,
+		MaxConcurrent: speedTester.MaxConcurrent,
+	}
+	for _, test := range speedTester.ActiveTests() {
+		if test.State == tester.StateQueued {
+			check.Queued++
+		} else {
+			check.Running++
+		}
+	}
+	return check
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Test History ====================
+
+const (
+	defaultHistorySize = 100
+	maxSamplesPerTest  = 20000
+	pendingSamplesTTL  = 10 * time.Minute // samples of tests that never completed
+)
+
+// historyRecord is a history entry with its sample series
+type historyRecord struct {
+	Entry   *api.HistoryEntry `json:"entry"`
+	Samples []api.Sample      `json:"samples"`
+}
+
+// pendingSamples collects samples until the test result arrives
+type pendingSamples struct {
+	samples []api.Sample
+	updated time.Time
+}
+
+// historyStore keeps the last size completed tests in memory,
+// optionally persisted as JSON lines (HISTORY_FILE)
+type historyStore struct {
+	mu      sync.RWMutex
+	size    int
+	records []*historyRecord // oldest first
+	byID    map[string]*historyRecord
+	pending map[string]*pendingSamples
+
+	path    string // "" = memory only
+	lines   int    // records appended to path since the last rewrite
+	lastErr error  // last persistence error
+}
+
+// history is the store behind /speedtest/history, set up by serve
+var history = newHistoryStore(defaultHistorySize, "")
+
+func newHistoryStore(size int, path string) *historyStore {
+	return &historyStore{
+		size:    size,
+		byID:    make(map[string]*historyRecord),
+		pending: make(map[string]*pendingSamples),
+		path:    path,
+	}
+}
+
+// loadHistoryConfig reads HISTORY_SIZE and HISTORY_FILE and loads persisted entries
+func loadHistoryConfig() (*historyStore, error) {
+	size := defaultHistorySize
+	if v := os.Getenv("HISTORY_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid HISTORY_SIZE %q", v)
+		}
+		size = n
+	}
+
+	store := newHistoryStore(size, os.Getenv("HISTORY_FILE"))
+	if store.path != "" {
+		if err := store.load(); err != nil {
+			return nil, fmt.Errorf("failed to load HISTORY_FILE: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// attach records every completed test and its samples from t
+func (h *historyStore) attach(t *tester.Tester) {
+	t.OnSample(h.addSample)
+	t.OnResult(h.addResult)
+}
+
+// addSample buffers a sample until its test completes
+func (h *historyStore) addSample(s tester.Sample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.pending[s.TestID]
+	if !ok {
+		h.prunePending()
+		p = &pendingSamples{}
+		h.pending[s.TestID] = p
+	}
+	p.updated = time.Now()
+	if len(p.samples) >= maxSamplesPerTest {
+		return
+	}
+	sample := api.Sample{
+		TimestampMs: s.Time.UnixMilli(),
+		ElapsedSec:  s.Elapsed.Seconds(),
+		SpeedMbps:   s.SpeedMbps,
+		Bytes:       s.Bytes,
+	}
+	if s.LoadedLatency > 0 {
+		sample.LoadedLatencyMs = float64(s.LoadedLatency.Microseconds()) / 1000
+	}
+	p.samples = append(p.samples, sample)
+}
+
+// prunePending drops samples of tests that failed or were cancelled
+func (h *historyStore) prunePending() {
+	for id, p := range h.pending {
+		if time.Since(p.updated) > pendingSamplesTTL {
+			delete(h.pending, id)
+		}
+	}
+}
+
+// addResult stores a completed test with its buffered samples
+func (h *historyStore) addResult(result interface{}) {
+	entry := &api.HistoryEntry{Result: result}
+	switch r := result.(type) {
+	case *api.PingResponse:
+		entry.ID, entry.Type, entry.Timestamp = r.TestID, tester.PhasePing, r.Timestamp
+	case *api.DownloadResponse:
+		entry.ID, entry.Type, entry.Timestamp = r.TestID, tester.PhaseDownload, r.Timestamp
+	case *api.UploadResponse:
+		entry.ID, entry.Type, entry.Timestamp = r.TestID, tester.PhaseUpload, r.Timestamp
+	default:
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record := &historyRecord{Entry: entry}
+	if p, ok := h.pending[entry.ID]; ok {
+		record.Samples = p.samples
+		delete(h.pending, entry.ID)
+	}
+	entry.SampleCount = len(record.Samples)
+	h.insert(record)
+	h.persist(record)
+}
+
+// insert appends record, evicting the oldest beyond size; caller holds mu
+func (h *historyStore) insert(record *historyRecord) {
+	h.records = append(h.records, record)
+	h.byID[record.Entry.ID] = record
+	if len(h.records) > h.size {
+		evicted := h.records[0]
+		delete(h.byID, evicted.Entry.ID)
+		h.records = h.records[1:]
+	}
+}
+
+// persist appends record to the history file, rewriting it when it grows
+// past twice the store size; caller holds mu
+func (h *historyStore) persist(record *historyRecord) {
+	if h.path == "" {
+		return
+	}
+
+	var err error
+	if h.lines >= 2*h.size {
+		err = h.rewrite()
+	} else {
+		err = appendJSONLine(h.path, record)
+		h.lines++
+	}
+	if err != nil && h.lastErr == nil {
+		slog.Error("history write failed", "path", h.path, "error", err)
+	}
+	h.lastErr = err
+}
+
+// rewrite replaces the history file with the current records; caller holds mu
+func (h *historyStore) rewrite() error {
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, record := range h.records {
+		if err := enc.Encode(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return err
+	}
+	h.lines = len(h.records)
+	return nil
+}
+
+func appendJSONLine(path string, v interface{}) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load reads persisted records; a missing file is an empty history
+func (h *historyStore) load() error {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // lines with 20k samples
+	for scanner.Scan() {
+		var raw struct {
+			Entry struct {
+				api.HistoryEntry
+				Result json.RawMessage `json:"result"`
+			} `json:"entry"`
+			Samples []api.Sample `json:"samples"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			slog.Warn("history: skipping corrupt line", "path", h.path, "error", err)
+			continue
+		}
+
+		// Decode the result into its concrete type so it round-trips
+		entry := raw.Entry.HistoryEntry
+		var result interface{}
+		switch entry.Type {
+		case tester.PhasePing:
+			result = &api.PingResponse{}
+		case tester.PhaseDownload:
+			result = &api.DownloadResponse{}
+		case tester.PhaseUpload:
+			result = &api.UploadResponse{}
+		default:
+			continue
+		}
+		if err := json.Unmarshal(raw.Entry.Result, result); err != nil {
+			continue
+		}
+		entry.Result = result
+		h.insert(&historyRecord{Entry: &entry, Samples: raw.Samples})
+		h.lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	slog.Info("history loaded", "path", h.path, "entries", len(h.records))
+	return nil
+}
+
+// Err returns the last persistence error (nil for memory-only stores)
+func (h *historyStore) Err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
+
+// check probes that the history file can still be opened for appending
+// (nil for memory-only stores); used by /readyz
+func (h *historyStore) check() error {
+	if h.path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// len returns the number of stored tests
+func (h *historyStore) len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.records)
+}
+
+// list returns up to limit entries, newest first
+func (h *historyStore) list(limit int) []*api.HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := make([]*api.HistoryEntry, 0, limit)
+	for i := len(h.records) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, h.records[i].Entry)
+	}
+	return entries
+}
+
+// get returns the record with id
+func (h *historyStore) get(id string) (*historyRecord, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	record, ok := h.byID[id]
+	return record, ok
+}
+
+// ==================== History Handlers ====================
+
+// speedtestHistoryHandler - GET /speedtest/history
+// Completed tests, newest first. Optional query: ?limit=20
+func speedtestHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	limit := history.size
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid limit %q", v))
+			return
+		}
+		limit = min(n, history.size)
+	}
+
+	entries := history.list(limit)
+	writeJSON(w, http.StatusOK, api.HistoryResponse{Count: len(entries), Entries: entries})
+}
+
+// lookupHistory resolves {id} or writes 404
+func lookupHistory(w http.ResponseWriter, r *http.Request) (*historyRecord, bool) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return nil, false
+	}
+	record, ok := history.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no test %q in history", r.PathValue("id")))
+		return nil, false
+	}
+	return record, true
+}
+
+// speedtestHistoryEntryHandler - GET /speedtest/history/{id}
+func speedtestHistoryEntryHandler(w http.ResponseWriter, r *http.Request) {
+	record, ok := lookupHistory(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, record.Entry)
+}
+
+// speedtestHistorySamplesHandler - GET /speedtest/history/{id}/samples
+// Per-sample throughput series of a download or upload
+func speedtestHistorySamplesHandler(w http.ResponseWriter, r *http.Request) {
+	record, ok := lookupHistory(w, r)
+	if !ok {
+		return
+	}
+	samples := record.Samples
+	if samples == nil {
+		samples = []api.Sample{}
+	}
+	writeJSON(w, http.StatusOK, api.SamplesResponse{
+		ID:      record.Entry.ID,
+		Type:    record.Entry.Type,
+		Count:   len(samples),
+		Samples: samples,
+	})
+}
+
+// ==================== Replay ====================
+
+const maxReplaySpeed = 100
+
+// replayEvent is a stream event and when it was sent, relative to the test start
+type replayEvent struct {
+	offset time.Duration
+	event  StreamEvent
+}
+
+// replayEvents rebuilds the start/progress/complete sequence that
+// /speedtest/{download,upload}/stream sent for a recorded transfer
+func replayEvents(record *historyRecord) ([]replayEvent, bool) {
+	_, r := api.Transfer(record.Entry.Result)
+	if r == nil {
+		return nil, false
+	}
+
+	// The result is stamped when the transfer ends
+	start := r.Timestamp - r.DurationMs
+	if len(record.Samples) > 0 {
+		first := record.Samples[0]
+		start = min(start, first.TimestampMs-int64(first.ElapsedSec*1000))
+	}
+	at := func(ms int64) time.Duration { return time.Duration(max(ms-start, 0)) * time.Millisecond }
+
+	events := []replayEvent{{event: StreamEvent{
+		Type:     "start",
+		ServerID: r.ServerID,
+		Sponsor:  r.Sponsor, Location: r.Location,
+		Latency:   r.Latency,
+		IPVersion: r.IPVersion,
+		Link:      r.Link,
+		Proxy:     r.Proxy,
+
+		AssumedLocation: r.AssumedLocation,
+	}}}
+	for _, s := range record.Samples {
+		events = append(events, replayEvent{offset: at(s.TimestampMs), event: StreamEvent{
+			Type:      "progress",
+			SpeedMbps: s.SpeedMbps,
+			Elapsed:   s.ElapsedSec,
+			IPVersion: r.IPVersion,
+		}})
+	}
+	events = append(events, replayEvent{offset: at(r.Timestamp), event: StreamEvent{
+		Type:      "complete",
+		TestID:    r.TestID,
+		SpeedMbps: r.SpeedMbps,
+		Elapsed:   float64(r.DurationMs) / 1000,
+		ServerID:  r.ServerID,
+		Sponsor:   r.Sponsor, Location: r.Location,
+		Latency:   r.Latency,
+		IPVersion: r.IPVersion,
+		Link:      r.Link,
+		Proxy:     r.Proxy,
+	}})
+	return events, true
+}
+
+// speedtestHistoryReplayHandler - GET /speedtest/history/{id}/replay
+// Re-emits a recorded download/upload as SSE with the original timing.
+// Optional query: ?speed=2 (playback multiplier, default 1, max 100)
+func speedtestHistoryReplayHandler(w http.ResponseWriter, r *http.Request) {
+	record, ok := lookupHistory(w, r)
+	if !ok {
+		return
+	}
+
+	speed := 1.0
+	if v := r.URL.Query().Get("speed"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || !isFinite(f) || f <= 0 || f > maxReplaySpeed {
+			writeError(w, http.StatusBadRequest, "invalid_request",
+				fmt.Sprintf("invalid speed %q (0 < speed <= %d)", v, maxReplaySpeed))
+			return
+		}
+		speed = f
+	}
+
+	events, ok := replayEvents(record)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request",
+			fmt.Sprintf("test %q is a %s test; only download and upload can be replayed", record.Entry.ID, record.Entry.Type))
+		return
+	}
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	slog.InfoContext(r.Context(), "replaying test", "test_id", record.Entry.ID, "type", record.Entry.Type,
+		"events", len(events), "speed", speed)
+
+	begin := time.Now()
+	for _, e := range events {
+		wait := time.Until(begin.Add(time.Duration(float64(e.offset) / speed)))
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		sendSSE(w, flusher, e.event)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==================== InfluxDB Line Protocol ====================
+
+// influxUDPMaxPacket keeps datagrams below a typical MTU-safe size
+const influxUDPMaxPacket = 8192
+
+// Line protocol has no escape for line breaks, so they become (escaped) spaces
+var (
+	measurementEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, " ", `\ `, "\n", `\ `, "\r", `\ `)
+	tagEscaper         = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `, "\r", `\ `)
+)
+
+// lineProtocol encodes p as one InfluxDB line (nanosecond precision)
+func lineProtocol(p metricPoint) string {
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(p.measurement))
+
+	keys := make([]string, 0, len(p.tags))
+	for k := range p.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // sorted tags are faster to ingest
+	for _, k := range keys {
+		b.WriteString("," + tagEscaper.Replace(k) + "=" + tagEscaper.Replace(p.tags[k]))
+	}
+
+	fields := make([]string, 0, len(p.fields))
+	for k := range p.fields {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for i, k := range fields {
+		sep := ","
+		if i == 0 {
+			sep = " "
+		}
+		b.WriteString(sep + tagEscaper.Replace(k) + "=" + strconv.FormatFloat(p.fields[k], 'f', -1, 64))
+	}
+
+	b.WriteString(" " + strconv.FormatInt(p.time.UnixNano(), 10))
+	return b.String()
+}
+
+// influxHTTPSink writes to the InfluxDB HTTP write API (v1 /write or v2 /api/v2/write)
+type influxHTTPSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// newInfluxHTTPSink takes the full write URL, e.g.
+// http://influx:8086/api/v2/write?org=home&bucket=net or http://vm:8428/write?db=net
+func newInfluxHTTPSink(writeURL, token string) (*influxHTTPSink, error) {
+	u, err := url.Parse(writeURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	q := u.Query()
+	if q.Get("precision") == "" {
+		q.Set("precision", "ns")
+		u.RawQuery = q.Encode()
+	}
+	return &influxHTTPSink{url: u.String(), token: token, client: &http.Client{}}, nil
+}
+
+func (s *influxHTTPSink) Name() string { return "influx-http" }
+
+func (s *influxHTTPSink) Write(ctx context.Context, points []metricPoint) error {
+	var body bytes.Buffer
+	for _, p := range points {
+		body.WriteString(lineProtocol(p) + "\n")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	if !retryableStatus(resp.StatusCode) {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// influxUDPSink sends line protocol datagrams to an InfluxDB/Telegraf UDP listener
+type influxUDPSink struct {
+	addr string
+}
+
+func newInfluxUDPSink(addr string) (*influxUDPSink, error) {
+	if _, err := net.ResolveUDPAddr("udp", addr); err != nil {
+		return nil, err
+	}
+	return &influxUDPSink{addr: addr}, nil
+}
+
+func (s *influxUDPSink) Name() string { return "influx-udp" }
+
+func (s *influxUDPSink) Write(ctx context.Context, points []metricPoint) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Pack lines into datagrams; a line is never split
+	var packet bytes.Buffer
+	send := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, p := range points {
+		line := lineProtocol(p) + "\n"
+		if packet.Len()+len(line) > influxUDPMaxPacket {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+		packet.WriteString(line)
+	}
+	return send()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineProtocolEscaping(t *testing.T) {
+	p := metricPoint{
+		measurement: "speed test,x",
+		tags: map[string]string{
+			"sponsor":  "My ISP, Inc.",
+			"location": "a=b",
+			"link":     "wan 1",
+		},
+		fields: map[string]float64{
+			"speed_mbps": 95.5,
+			"latency ms": 12,
+			"a,b=c":      0.125,
+		},
+		time: time.Unix(0, 1700000000123456789),
+	}
+	want := `speed\ test\,x,link=wan\ 1,location=a\=b,sponsor=My\ ISP\,\ Inc.` +
+		` a\,b\=c=0.125,latency\ ms=12,speed_mbps=95.5 1700000000123456789`
+	if got := lineProtocol(p); got != want {
+		t.Errorf("lineProtocol:\n got %s\nwant %s", got, want)
+	}
+}
+
+// A backslash or line break in a server name must not break the batch
+func TestLineProtocolEscapesBackslashAndNewline(t *testing.T) {
+	p := metricPoint{
+		measurement: "speedtest",
+		tags: map[string]string{
+			"sponsor":  `PT Net\`,
+			"location": "Kota\nBaru\r",
+		},
+		fields: map[string]float64{"speed_mbps": 1},
+		time:   time.Unix(0, 1),
+	}
+	want := `speedtest,location=Kota\ Baru\ ,sponsor=PT\ Net\\ speed_mbps=1 1`
+	got := lineProtocol(p)
+	if got != want {
+		t.Errorf("lineProtocol:\n got %s\nwant %s", got, want)
+	}
+	if strings.ContainsAny(got, "\r\n") {
+		t.Errorf("lineProtocol %q contains a line break", got)
+	}
+}
+
+func TestInfluxHTTPSinkWrite(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := newInfluxHTTPSink(srv.URL+"/api/v2/write?org=home&bucket=net", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := []metricPoint{testPoint("download", 1), testPoint("upload", 2)}
+	if err := sink.Write(context.Background(), points); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if got.Method != http.MethodPost || got.URL.Path != "/api/v2/write" {
+		t.Errorf("request %s %s, want POST /api/v2/write", got.Method, got.URL.Path)
+	}
+	q := got.URL.Query()
+	if q.Get("precision") != "ns" || q.Get("org") != "home" || q.Get("bucket") != "net" {
+		t.Errorf("query %s, want precision=ns with org and bucket kept", got.URL.RawQuery)
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Token secret" {
+		t.Errorf("Authorization %q, want Token secret", auth)
+	}
+	want := lineProtocol(points[0]) + "\n" + lineProtocol(points[1]) + "\n"
+	if body != want {
+		t.Errorf("body:\n%s\nwant:\n%s", body, want)
+	}
+}
+
+func TestInfluxHTTPSinkStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status    int
+		ok        bool
+		permanent bool
+	}{
+		{http.StatusNoContent, true, false},
+		{http.StatusOK, true, false},
+		{http.StatusTooManyRequests, false, false},
+		{http.StatusInternalServerError, false, false},
+		{http.StatusServiceUnavailable, false, false},
+		{http.StatusBadRequest, false, true},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusNotFound, false, true},
+		{http.StatusRequestEntityTooLarge, false, true},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "status body", tc.status)
+		}))
+		sink, _ := newInfluxHTTPSink(srv.URL+"/write?db=net", "")
+		err := sink.Write(context.Background(), []metricPoint{testPoint("ping", 1)})
+		srv.Close()
+
+		var permanent *permanentError
+		switch {
+		case tc.ok && err != nil:
+			t.Errorf("HTTP %d: unexpected error %v", tc.status, err)
+		case !tc.ok && err == nil:
+			t.Errorf("HTTP %d: no error", tc.status)
+		case !tc.ok && errors.As(err, &permanent) != tc.permanent:
+			t.Errorf("HTTP %d: permanent = %v, want %v (%v)", tc.status, !tc.permanent, tc.permanent, err)
+		case !tc.ok && !strings.Contains(err.Error(), "status body"):
+			t.Errorf("HTTP %d: error %q does not include the response body", tc.status, err)
+		}
+	}
+}
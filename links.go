@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"go-speedtest/api"
+)
+
+// ==================== Named Links ====================
+
+// Link is a named uplink: tests bind to its source IP or network interface
+type Link = api.Link
+
+// links holds configured links by name (from SPEEDTEST_LINKS)
+var links = map[string]*Link{}
+
+// parseLinks parses "wan1=eth0,wan2=192.168.2.10" into named links.
+// A value that parses as an IP is a source address, otherwise an interface name.
+func parseLinks(spec string) (map[string]*Link, error) {
+	result := map[string]*Link{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid link %q (want name=interface or name=ip)", entry)
+		}
+		if _, dup := result[name]; dup {
+			return nil, fmt.Errorf("duplicate link %q", name)
+		}
+
+		link := &Link{Name: name}
+		if ip := net.ParseIP(value); ip != nil {
+			link.SourceIP = ip.String()
+		} else {
+			link.Interface = value
+		}
+		result[name] = link
+	}
+	return result, nil
+}
+
+// loadLinkConfig reads named links from SPEEDTEST_LINKS
+func loadLinkConfig() (map[string]*Link, error) {
+	result, err := parseLinks(os.Getenv("SPEEDTEST_LINKS"))
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range result {
+		if link.Interface == "" {
+			continue
+		}
+		// Interface may come up later (PPP, VPN), so only warn
+		if _, err := net.InterfaceByName(link.Interface); err != nil {
+			slog.Warn("link interface not found", "link", link.Name, "interface", link.Interface, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// lookupLink resolves a link name from the link query param
+func lookupLink(name string) (*Link, error) {
+	if name == "" {
+		return nil, nil
+	}
+	link, ok := links[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown link %q", name)
+	}
+	return link, nil
+}
+
+// speedtestLinksHandler - GET /speedtest/links
+// Returns configured links usable via ?link=name
+func speedtestLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, linksResponse())
+}
+
+// linksResponse lists the configured links sorted by name
+func linksResponse() api.LinksResponse {
+	linkList := make([]*Link, 0, len(links))
+	for _, link := range links {
+		linkList = append(linkList, link)
+	}
+	sort.Slice(linkList, func(i, j int) bool { return linkList[i].Name < linkList[j].Name })
+
+	return api.LinksResponse{
+		Count: len(linkList),
+		Links: linkList,
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Live Hub ====================
+//
+// Every ping, download and upload publishes its events to the hub, whichever API
+// started it (HTTP, SSE, WebSocket, gRPC, MQTT). /speedtest/live streams
+// them to any number of viewers, e.g. a NOC wall display.
+
+const (
+	liveViewerBuffer = 64
+	liveKeepAlive    = 15 * time.Second
+)
+
+// liveTest is a running test: its start event and latest progress
+type liveTest struct {
+	start    StreamEvent
+	progress *StreamEvent
+}
+
+// liveViewer receives events of one test, or of all tests when testID is ""
+type liveViewer struct {
+	testID string
+	events chan StreamEvent
+}
+
+// liveHub fans test events out to viewers
+type liveHub struct {
+	mu      sync.Mutex
+	tests   map[string]*liveTest
+	viewers map[*liveViewer]struct{}
+}
+
+// live is the hub behind /speedtest/live, attached to speedTester by serve
+var live = &liveHub{
+	tests:   make(map[string]*liveTest),
+	viewers: make(map[*liveViewer]struct{}),
+}
+
+// attach publishes the events of every test run by t
+func (h *liveHub) attach(t *tester.Tester) {
+	t.OnStart(h.onStart)
+	t.OnSample(h.onSample)
+	t.OnResult(h.onResult)
+	t.OnFailure(h.onFailure)
+}
+
+func (h *liveHub) onStart(s tester.Start) {
+	event := StreamEvent{
+		Type:     "start",
+		TestID:   s.TestID,
+		Phase:    s.Phase,
+		ServerID: s.Server.ID,
+		Sponsor:  s.Server.Sponsor, Location: s.Server.Name,
+		Latency:   s.LatencyMs,
+		IPVersion: s.IPVersion,
+		Link:      s.Link,
+		Proxy:     s.Proxy,
+
+		AssumedLocation: s.AssumedLocation,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tests[s.TestID] = &liveTest{start: event}
+	h.broadcast(event)
+}
+
+func (h *liveHub) onSample(s tester.Sample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	test, ok := h.tests[s.TestID]
+	if !ok {
+		return
+	}
+	event := StreamEvent{
+		Type:      "progress",
+		TestID:    s.TestID,
+		Phase:     s.Phase,
+		SpeedMbps: s.SpeedMbps,
+		Elapsed:   s.Elapsed.Seconds(),
+		IPVersion: s.IPVersion,
+	}
+	test.progress = &event
+	h.broadcast(event)
+}
+
+func (h *liveHub) onResult(result interface{}) {
+	if r, ok := result.(*PingResponse); ok {
+		h.finish(StreamEvent{
+			Type:     "complete",
+			TestID:   r.TestID,
+			Phase:    tester.PhasePing,
+			ServerID: r.ServerID,
+			Sponsor:  r.Sponsor, Location: r.Location,
+			Latency:   r.Latency,
+			IPVersion: r.IPVersion,
+			Link:      r.Link,
+			Proxy:     r.Proxy,
+		})
+		return
+	}
+
+	phase, r := api.Transfer(result)
+	if r == nil {
+		return
+	}
+	h.finish(StreamEvent{
+		Type:      "complete",
+		TestID:    r.TestID,
+		Phase:     phase,
+		SpeedMbps: r.SpeedMbps,
+		Elapsed:   float64(r.DurationMs) / 1000,
+		ServerID:  r.ServerID,
+		Sponsor:   r.Sponsor, Location: r.Location,
+		Latency:   r.Latency,
+		IPVersion: r.IPVersion,
+		Link:      r.Link,
+		Proxy:     r.Proxy,
+	})
+}
+
+func (h *liveHub) onFailure(f tester.Failure) {
+	message := f.Err.Error()
+	if errors.Is(f.Err, context.Canceled) {
+		message = "Test cancelled"
+	}
+	h.finish(StreamEvent{Type: "error", TestID: f.TestID, Phase: f.Phase, Message: message})
+}
+
+// finish broadcasts the last event of a test and forgets it
+func (h *liveHub) finish(event StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	test, ok := h.tests[event.TestID]
+	if !ok {
+		return
+	}
+	event.IPVersion = test.start.IPVersion
+	delete(h.tests, event.TestID)
+	h.broadcast(event)
+}
+
+// broadcast sends event to the matching viewers; caller holds mu.
+// A viewer that cannot keep up is disconnected rather than blocking the
+// test; EventSource reconnects and gets a fresh snapshot.
+func (h *liveHub) broadcast(event StreamEvent) {
+	for v := range h.viewers {
+		if v.testID != "" && v.testID != event.TestID {
+			continue
+		}
+		select {
+		case v.events <- event:
+		default:
+			slog.Warn("live viewer too slow, disconnecting", "test_id", v.testID)
+			delete(h.viewers, v)
+			close(v.events)
+		}
+	}
+}
+
+// subscribe registers a viewer of testID ("" = all tests) and returns the
+// start and latest progress events of the running tests it should see first.
+// ok is false when testID is not running.
+func (h *liveHub) subscribe(testID string) (v *liveViewer, snapshot []StreamEvent, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if testID != "" {
+		if _, running := h.tests[testID]; !running {
+			return nil, nil, false
+		}
+	}
+	for id, test := range h.tests {
+		if testID != "" && id != testID {
+			continue
+		}
+		snapshot = append(snapshot, test.start)
+		if test.progress != nil {
+			snapshot = append(snapshot, *test.progress)
+		}
+	}
+
+	v = &liveViewer{testID: testID, events: make(chan StreamEvent, liveViewerBuffer)}
+	h.viewers[v] = struct{}{}
+	return v, snapshot, true
+}
+
+// unsubscribe removes v unless broadcast already dropped it
+func (h *liveHub) unsubscribe(v *liveViewer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.viewers[v]; ok {
+		delete(h.viewers, v)
+		close(v.events)
+	}
+}
+
+// speedtestLiveHandler - GET /speedtest/live and /speedtest/live/{id}
+// Streams events of all running tests (or one test) as SSE. The stream for
+// one test ends with its complete or error event.
+func speedtestLiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	testID := r.PathValue("id")
+	viewer, snapshot, ok := live.subscribe(testID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no running test %q", testID))
+		return
+	}
+	defer live.unsubscribe(viewer)
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	for _, event := range snapshot {
+		sendSSE(w, flusher, event)
+	}
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event, ok := <-viewer.events:
+			if !ok {
+				return
+			}
+			sendSSE(w, flusher, event)
+			if testID != "" && (event.Type == "complete" || event.Type == "error") {
+				return
+			}
+		}
+	}
+}
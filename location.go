@@ -0,0 +1,121 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"go-speedtest/api"
+)
+
+// ==================== Location Override ====================
+
+// Location sources reported in AssumedLocation.Source
+const (
+	LocationSourceQuery  = "query"
+	LocationSourceConfig = "config"
+)
+
+//go:embed cities.csv
+var citiesCSV string
+
+// AssumedLocation represents the client location used for server distances
+type AssumedLocation = api.AssumedLocation
+
+// cities is the embedded city dataset, keyed by normalized city name
+var cities = loadCities(citiesCSV)
+
+// defaultLocation is the configured location override (nil = Ookla geo-IP)
+var defaultLocation *AssumedLocation
+
+// cityKey normalizes a city name for lookup ("Kuala Lumpur" -> "kualalumpur")
+func cityKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// loadCities parses the embedded cities.csv (name,country,lat,lon)
+func loadCities(data string) map[string]AssumedLocation {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded cities.csv: %v", err))
+	}
+
+	result := make(map[string]AssumedLocation, len(records))
+	for i, rec := range records {
+		if i == 0 || len(rec) != 4 {
+			continue // header
+		}
+		lat, errLat := strconv.ParseFloat(rec[2], 64)
+		lon, errLon := strconv.ParseFloat(rec[3], 64)
+		if errLat != nil || errLon != nil {
+			panic(fmt.Sprintf("invalid coordinates for %s in cities.csv", rec[0]))
+		}
+		result[cityKey(rec[0])] = AssumedLocation{
+			Name:    rec[0],
+			Country: rec[1],
+			Lat:     lat,
+			Lon:     lon,
+		}
+	}
+	return result
+}
+
+// lookupCity finds a city in the embedded dataset
+func lookupCity(name string) (*AssumedLocation, error) {
+	city, ok := cities[cityKey(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown city %q", name)
+	}
+	return &city, nil
+}
+
+// parseLocation builds a location from a city name or lat/lon strings.
+// Coordinates take precedence over city; returns nil when nothing is set.
+func parseLocation(city, latStr, lonStr, source string) (*AssumedLocation, error) {
+	if latStr != "" || lonStr != "" {
+		if latStr == "" || lonStr == "" {
+			return nil, fmt.Errorf("both lat and lon are required")
+		}
+		// NaN passes range comparisons, so reject non-finite values explicitly
+		lat, err := strconv.ParseFloat(latStr, 64)
+		if err != nil || !isFinite(lat) || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("invalid lat %q", latStr)
+		}
+		lon, err := strconv.ParseFloat(lonStr, 64)
+		if err != nil || !isFinite(lon) || lon < -180 || lon > 180 {
+			return nil, fmt.Errorf("invalid lon %q", lonStr)
+		}
+		return &AssumedLocation{Name: city, Lat: lat, Lon: lon, Source: source}, nil
+	}
+
+	if city != "" {
+		loc, err := lookupCity(city)
+		if err != nil {
+			return nil, err
+		}
+		loc.Source = source
+		return loc, nil
+	}
+
+	return nil, nil
+}
+
+// isFinite reports whether f is neither NaN nor ±Inf
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// loadLocationConfig reads the location override from environment
+// SPEEDTEST_LAT + SPEEDTEST_LON, atau SPEEDTEST_CITY
+func loadLocationConfig() (*AssumedLocation, error) {
+	return parseLocation(
+		os.Getenv("SPEEDTEST_CITY"),
+		os.Getenv("SPEEDTEST_LAT"),
+		os.Getenv("SPEEDTEST_LON"),
+		LocationSourceConfig,
+	)
+}
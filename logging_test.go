@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidRequestID(t *testing.T) {
+	for _, tc := range []struct {
+		id   string
+		want bool
+	}{
+		{"0123456789abcdef", true},
+		{"req-42_a.b:c/d", true},
+		{strings.Repeat("a", 128), true},
+		{"", false},
+		{strings.Repeat("a", 129), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"fake\r\nX-Injected: 1", false},
+		{"tab\there", false},
+		{"nul\x00", false},
+		{"del\x7f", false},
+		{"ünïcode", false},
+	} {
+		if got := validRequestID(tc.id); got != tc.want {
+			t.Errorf("validRequestID(%q) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r.Context())
+	})
+
+	for _, tc := range []struct {
+		header string
+		keep   bool
+	}{
+		{"client-id-1", true},
+		{"", false},
+		{"bad id\n", false},
+		{strings.Repeat("x", 200), false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			r.Header.Set(RequestIDHeader, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+
+		echoed := rec.Header().Get(RequestIDHeader)
+		if echoed != seen || !validRequestID(echoed) {
+			t.Errorf("%q: response ID %q, context ID %q, want the same valid ID", tc.header, echoed, seen)
+		}
+		if tc.keep != (echoed == tc.header) {
+			t.Errorf("%q: response ID %q, want kept=%v", tc.header, echoed, tc.keep)
+		}
+	}
+}
+
+func TestContextHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewTextHandler(&buf, nil)})
+
+	logger.InfoContext(withRequestID(context.Background(), "req-1"), "hello")
+	logger.InfoContext(context.Background(), "outside")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "request_id=req-1") || strings.Contains(lines[1], "request_id") {
+		t.Errorf("log lines %q, want request_id on the first only", lines)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== Middleware ====================
+//
+// Every HTTP route (API, streams, WebSocket, admin and UI) is served
+// through the same stack, built by serve with newMiddlewareStack:
+//
+//	tracing -> request ID -> access log -> recovery -> security headers -> CORS -> handler
+//
+// so the access log and traces see the 500 written by the recovery, and
+// the panic log line carries the request ID.
+
+// middleware wraps a handler
+type middleware func(http.HandlerFunc) http.HandlerFunc
+
+// chain composes middlewares; the first one is the outermost
+func chain(mws ...middleware) middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// newMiddlewareStack returns the stack applied to every route
+func newMiddlewareStack(policy *corsConfig, accessLog bool) middleware {
+	mws := []middleware{tracingMiddleware, requestIDMiddleware}
+	if accessLog {
+		mws = append(mws, accessLogMiddleware)
+	}
+	return chain(append(mws, recoveryMiddleware, securityHeadersMiddleware, policy.middleware)...)
+}
+
+// requesterMiddleware tags tests started by the request with the client address (see /admin/tests)
+func requesterMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(tester.WithRequester(r.Context(), "http "+r.RemoteAddr)))
+	}
+}
+
+// ==================== Recovery ====================
+
+// recoveryMiddleware turns a handler panic into a logged 500 instead of a
+// dropped connection
+func recoveryMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := newStatusRecorder(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// Deliberate abort of the response, not a bug
+				panic(p)
+			}
+
+			slog.ErrorContext(r.Context(), "handler panic", "method", r.Method, "path", r.URL.Path,
+				"panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+			if rec.wroteHeader {
+				// Streaming already started; the client sees the stream end
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, api.V2Prefix+"/") {
+				writeProblem(rec, r, http.StatusInternalServerError, api.CodeInternal, "Internal server error")
+				return
+			}
+			writeError(rec, http.StatusInternalServerError, "internal_error", "Internal server error")
+		}()
+		next(rec, r)
+	}
+}
+
+// ==================== Access Log ====================
+
+// probePaths are logged at debug level: load balancers poll them constantly
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// loadAccessLog reads ACCESS_LOG (default true)
+func loadAccessLog() (bool, error) {
+	v := os.Getenv("ACCESS_LOG")
+	if v == "" {
+		return true, nil
+	}
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid ACCESS_LOG %q (true, false)", v)
+	}
+	return enabled, nil
+}
+
+// accessLogMiddleware logs one line per request once it completes; for
+// streams and WebSocket that is when the connection ends
+func accessLogMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next(rec, r)
+
+		level := slog.LevelInfo
+		if probePaths[r.URL.Path] {
+			level = slog.LevelDebug
+		}
+		slog.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+	}
+}
+
+// ==================== Security Headers ====================
+
+// contentSecurityPolicy allows the embedded UI (inline docs script, same-origin API calls)
+const contentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; " +
+	"frame-ancestors 'none'; base-uri 'none'; form-action 'self'"
+
+// securityHeadersMiddleware sets browser hardening headers on every response
+func securityHeadersMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+		next(w, r)
+	}
+}
+
+// ==================== CORS Middleware ====================
+
+// corsConfig is the CORS policy (CORS_ALLOWED_ORIGINS, CORS_ALLOW_CREDENTIALS),
+// also used by the WebSocket origin check
+type corsConfig struct {
+	allowAll    bool            // "*": any origin, without credentials
+	origins     map[string]bool // normalized scheme://host[:port]
+	credentials bool
+}
+
+// cors is the policy set up by serve; any origin by default
+var cors = &corsConfig{allowAll: true}
+
+// loadCORSConfig reads CORS_ALLOWED_ORIGINS (comma-separated origins or
+// "*", default "*") and CORS_ALLOW_CREDENTIALS
+func loadCORSConfig() (*corsConfig, error) {
+	cfg := &corsConfig{origins: make(map[string]bool)}
+	v := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if v == "" {
+		v = "*"
+	}
+	for _, origin := range strings.Split(v, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			cfg.allowAll = true
+			continue
+		}
+		normalized, err := normalizeOrigin(origin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS entry %q: %w", origin, err)
+		}
+		cfg.origins[normalized] = true
+	}
+	if cfg.allowAll && len(cfg.origins) > 0 {
+		return nil, errors.New("CORS_ALLOWED_ORIGINS cannot mix * with origins")
+	}
+
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		credentials, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS %q (true, false)", v)
+		}
+		cfg.credentials = credentials
+	}
+	if cfg.credentials && cfg.allowAll {
+		// Browsers reject credentials with a wildcard origin
+		return nil, errors.New("CORS_ALLOW_CREDENTIALS requires an explicit CORS_ALLOWED_ORIGINS list")
+	}
+	return cfg, nil
+}
+
+// normalizeOrigin lowercases an origin and checks it is scheme://host[:port]
+func normalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+		return "", errors.New("want scheme://host[:port]")
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// allowed reports whether a browser page from origin may call the API
+func (c *corsConfig) allowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+	normalized, err := normalizeOrigin(origin)
+	return err == nil && c.origins[normalized]
+}
+
+// middleware adds CORS headers untuk browser-based clients and answers
+// preflight (OPTIONS) requests. With an origin list, requests from other
+// origins get no CORS headers, so browsers block the response.
+func (c *corsConfig) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		if !c.allowAll {
+			h.Add("Vary", "Origin")
+		}
+
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions
+		if c.allowAll || (origin != "" && c.allowed(origin)) {
+			if c.allowAll {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if c.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Content-Length, Accept-Encoding, X-Request-ID, traceparent, tracestate")
+			h.Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, X-Request-ID")
+		} else if origin != "" && preflight {
+			writeError(w, http.StatusForbidden, "origin_not_allowed", "Origin is not allowed by CORS_ALLOWED_ORIGINS")
+			return
+		}
+
+		if preflight {
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// checkWebSocketOrigin applies the CORS policy to WebSocket handshakes;
+// same-origin pages and non-browser clients (no Origin) are always allowed
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return cors.allowed(origin)
+}
+
+// ==================== Response Recorder ====================
+
+// statusRecorder remembers the response status and size; it keeps the
+// Flusher (SSE) and Hijacker (WebSocket) interfaces of the wrapped writer
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking not supported")
+	}
+	w.status, w.wroteHeader = http.StatusSwitchingProtocols, true
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
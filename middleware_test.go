@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		origin, want string
+	}{
+		{"https://Example.COM", "https://example.com"},
+		{"http://localhost:3000", "http://localhost:3000"},
+		{"https://example.com/", "https://example.com"},
+		{"example.com", ""},
+		{"https://", ""},
+		{"https://example.com/app", ""},
+		{"https://example.com?x=1", ""},
+		{"https://user@example.com", ""},
+		{"://bad", ""},
+	} {
+		got, err := normalizeOrigin(tc.origin)
+		if tc.want == "" && err == nil {
+			t.Errorf("normalizeOrigin(%q) = %q, want an error", tc.origin, got)
+		} else if tc.want != "" && got != tc.want {
+			t.Errorf("normalizeOrigin(%q) = %q, %v; want %q", tc.origin, got, err, tc.want)
+		}
+	}
+}
+
+func TestLoadCORSConfig(t *testing.T) {
+	for _, tc := range []struct {
+		origins, credentials string
+		allowAll             bool
+		allowed              []string
+		err                  bool
+	}{
+		{"", "", true, nil, false},
+		{"*", "false", true, nil, false},
+		{" https://a.example , http://localhost:3000 ,", "true", false, []string{"https://a.example", "http://localhost:3000"}, false},
+		{"*, https://a.example", "", false, nil, true},
+		{"a.example", "", false, nil, true},
+		{"*", "true", false, nil, true}, // browsers reject credentials with *
+		{"https://a.example", "maybe", false, nil, true},
+	} {
+		t.Setenv("CORS_ALLOWED_ORIGINS", tc.origins)
+		t.Setenv("CORS_ALLOW_CREDENTIALS", tc.credentials)
+		cfg, err := loadCORSConfig()
+		if (err != nil) != tc.err {
+			t.Errorf("%q credentials=%q: error %v, want error=%v", tc.origins, tc.credentials, err, tc.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if cfg.allowAll != tc.allowAll || len(cfg.origins) != len(tc.allowed) {
+			t.Errorf("%q: allowAll=%v origins=%v", tc.origins, cfg.allowAll, cfg.origins)
+		}
+		for _, origin := range tc.allowed {
+			if !cfg.allowed(origin) {
+				t.Errorf("%q: %s not allowed", tc.origins, origin)
+			}
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	list := &corsConfig{origins: map[string]bool{"https://a.example": true}, credentials: true}
+	handler := func(cfg *corsConfig) http.HandlerFunc {
+		return cfg.middleware(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	}
+
+	for _, tc := range []struct {
+		name        string
+		cfg         *corsConfig
+		method      string
+		origin      string
+		status      int
+		allowOrigin string
+		credentials bool
+	}{
+		{"wildcard", cors, http.MethodGet, "https://any.example", http.StatusTeapot, "*", false},
+		{"wildcard preflight", cors, http.MethodOptions, "https://any.example", http.StatusNoContent, "*", false},
+		{"listed", list, http.MethodGet, "https://a.example", http.StatusTeapot, "https://a.example", true},
+		{"listed, other case", list, http.MethodGet, "https://A.example", http.StatusTeapot, "https://A.example", true},
+		{"listed preflight", list, http.MethodOptions, "https://a.example", http.StatusNoContent, "https://a.example", true},
+		// Not listed: the request runs, but the browser gets no CORS headers
+		{"other origin", list, http.MethodGet, "https://evil.example", http.StatusTeapot, "", false},
+		{"other origin preflight", list, http.MethodOptions, "https://evil.example", http.StatusForbidden, "", false},
+		{"no origin", list, http.MethodGet, "", http.StatusTeapot, "", false},
+	} {
+		r := httptest.NewRequest(tc.method, "/speedtest/ping", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler(tc.cfg)(rec, r)
+
+		h := rec.Header()
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+		if got := h.Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin %q, want %q", tc.name, got, tc.allowOrigin)
+		}
+		if got := h.Get("Access-Control-Allow-Credentials") == "true"; got != tc.credentials {
+			t.Errorf("%s: credentials %v, want %v", tc.name, got, tc.credentials)
+		}
+		if !tc.cfg.allowAll && h.Get("Vary") != "Origin" {
+			t.Errorf("%s: missing Vary: Origin for an origin list", tc.name)
+		}
+	}
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	saved := cors
+	t.Cleanup(func() { cors = saved })
+	cors = &corsConfig{origins: map[string]bool{"https://a.example": true}}
+
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},                          // non-browser client
+		{"http://speedtest.lan:8645", true}, // same origin as Host
+		{"https://a.example", true},
+		{"https://evil.example", false},
+		{"http://speedtest.lan:9999", false},
+		{"null", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/speedtest/ws", nil)
+		r.Host = "speedtest.lan:8645"
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := checkWebSocketOrigin(r); got != tc.want {
+			t.Errorf("Origin %q: allowed = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+
+	cors = &corsConfig{allowAll: true}
+	r := httptest.NewRequest(http.MethodGet, "/speedtest/ws", nil)
+	r.Header.Set("Origin", "https://evil.example")
+	if !checkWebSocketOrigin(r) {
+		t.Error("CORS_ALLOWED_ORIGINS=*: cross-origin WebSocket rejected")
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// ==================== MQTT Publisher ====================
+//
+// Publishes every completed result (HTTP, SSE, WebSocket, gRPC or MQTT
+// triggered) as retained JSON, registers Home Assistant sensors via MQTT
+// discovery and runs tests requested on the command topic.
+
+const (
+	mqttQueueSize   = 32
+	mqttTestTimeout = 2 * time.Minute
+)
+
+// mqttConfig is read from MQTT_* environment variables
+type mqttConfig struct {
+	Broker          string // tcp://host:1883, ssl://host:8883, ws://host/mqtt
+	Username        string
+	Password        string
+	ClientID        string
+	TopicPrefix     string // <prefix>/ping, /download, /upload, /error, /command, /status
+	Discovery       bool
+	DiscoveryPrefix string
+	NodeID          string // Home Assistant device id, [a-zA-Z0-9_-]
+}
+
+// loadMQTTConfig returns nil when MQTT_BROKER is not set
+func loadMQTTConfig() (*mqttConfig, error) {
+	broker := os.Getenv("MQTT_BROKER")
+	if broker == "" {
+		return nil, nil
+	}
+	if _, err := url.Parse(broker); err != nil || !strings.Contains(broker, "://") {
+		return nil, fmt.Errorf("invalid MQTT_BROKER %q (use tcp://host:1883)", broker)
+	}
+
+	hostname, _ := os.Hostname()
+	cfg := &mqttConfig{
+		Broker:          broker,
+		Username:        os.Getenv("MQTT_USERNAME"),
+		Password:        os.Getenv("MQTT_PASSWORD"),
+		ClientID:        envOr("MQTT_CLIENT_ID", "go-speedtest-"+hostname),
+		TopicPrefix:     strings.TrimSuffix(envOr("MQTT_TOPIC_PREFIX", "go-speedtest"), "/"),
+		Discovery:       os.Getenv("MQTT_DISCOVERY") != "false",
+		DiscoveryPrefix: strings.TrimSuffix(envOr("MQTT_DISCOVERY_PREFIX", "homeassistant"), "/"),
+		NodeID:          envOr("MQTT_NODE_ID", sanitizeNodeID("go_speedtest_"+hostname)),
+	}
+	if strings.ContainsAny(cfg.TopicPrefix, "+#") || cfg.TopicPrefix == "" {
+		return nil, fmt.Errorf("invalid MQTT_TOPIC_PREFIX %q", cfg.TopicPrefix)
+	}
+	if cfg.NodeID != sanitizeNodeID(cfg.NodeID) {
+		return nil, fmt.Errorf("invalid MQTT_NODE_ID %q (use letters, digits, _ and -)", cfg.NodeID)
+	}
+	return cfg, nil
+}
+
+// envOr returns the environment variable or def when unset
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// sanitizeNodeID replaces characters Home Assistant does not allow in discovery ids
+func sanitizeNodeID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+func (c *mqttConfig) topic(name string) string {
+	return c.TopicPrefix + "/" + name
+}
+
+// mqttMessage is a queued publish
+type mqttMessage struct {
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+// mqttPublisher publishes results and runs commands
+type mqttPublisher struct {
+	cfg     *mqttConfig
+	client  mqtt.Client
+	queue   chan mqttMessage
+	running atomic.Bool // a command-triggered test is running
+}
+
+// startMQTT connects to the broker and hooks into speedTester results.
+// Connection is retried in the background; results published while
+// disconnected are dropped once the queue is full.
+func startMQTT(cfg *mqttConfig) *mqttPublisher {
+	p := newMQTTPublisher(cfg)
+	p.client.Connect() // returns immediately with ConnectRetry; onConnect does the rest
+
+	speedTester.OnResult(p.publishResult)
+	go p.run()
+
+	slog.Info("mqtt publisher started", "broker", cfg.Broker, "prefix", cfg.TopicPrefix)
+	return p
+}
+
+// newMQTTPublisher creates the publisher and its (not yet connected) client
+func newMQTTPublisher(cfg *mqttConfig) *mqttPublisher {
+	p := &mqttPublisher{cfg: cfg, queue: make(chan mqttMessage, mqttQueueSize)}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10*time.Second).
+		SetWill(cfg.topic("status"), "offline", 1, true).
+		SetOnConnectHandler(p.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("mqtt connection lost", "error", err)
+		})
+	p.client = mqtt.NewClient(opts)
+	return p
+}
+
+// onConnect runs after every (re)connect: availability, discovery and command subscription
+func (p *mqttPublisher) onConnect(c mqtt.Client) {
+	slog.Info("mqtt connected", "broker", p.cfg.Broker)
+	c.Publish(p.cfg.topic("status"), 1, true, "online")
+
+	if p.cfg.Discovery {
+		for topic, payload := range p.discoveryConfigs() {
+			data, _ := json.Marshal(payload)
+			c.Publish(topic, 1, true, data)
+		}
+	}
+
+	token := c.Subscribe(p.cfg.topic("command"), 1, p.onCommand)
+	if token.Wait() && token.Error() != nil {
+		slog.Error("mqtt subscribe failed", "topic", p.cfg.topic("command"), "error", token.Error())
+	}
+}
+
+// run publishes queued messages
+func (p *mqttPublisher) run() {
+	for msg := range p.queue {
+		token := p.client.Publish(msg.topic, 1, msg.retained, msg.payload)
+		if !token.WaitTimeout(10*time.Second) || token.Error() != nil {
+			slog.Warn("mqtt publish failed", "topic", msg.topic, "error", token.Error())
+		}
+	}
+}
+
+// enqueue queues a publish without blocking the caller
+func (p *mqttPublisher) enqueue(topic string, v interface{}, retained bool) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	select {
+	case p.queue <- mqttMessage{topic: topic, payload: payload, retained: retained}:
+	default:
+		slog.Warn("mqtt queue full, dropping message", "topic", topic)
+	}
+}
+
+// publishResult is the speedTester.OnResult hook
+func (p *mqttPublisher) publishResult(result interface{}) {
+	switch result.(type) {
+	case *api.PingResponse:
+		p.enqueue(p.cfg.topic("ping"), result, true)
+	case *api.DownloadResponse:
+		p.enqueue(p.cfg.topic("download"), result, true)
+	case *api.UploadResponse:
+		p.enqueue(p.cfg.topic("upload"), result, true)
+	}
+}
+
+// ==================== Command Topic ====================
+
+// mqttCommand is the JSON form of a command; a plain "ping", "download",
+// "upload" or "run" payload is the same as {"test": "<payload>"}
+type mqttCommand struct {
+	Test    string            `json:"test"`              // ping, download, upload or run (download + upload)
+	Options map[string]string `json:"options,omitempty"` // Same keys as the HTTP query
+}
+
+// onCommand runs the requested test; results are published through the result hook
+func (p *mqttPublisher) onCommand(_ mqtt.Client, msg mqtt.Message) {
+	payload := strings.TrimSpace(string(msg.Payload()))
+	cmd := mqttCommand{Test: payload}
+	if strings.HasPrefix(payload, "{") {
+		cmd = mqttCommand{}
+		if err := json.Unmarshal(msg.Payload(), &cmd); err != nil {
+			p.publishError("invalid_request", fmt.Sprintf("invalid command: %v", err))
+			return
+		}
+	}
+
+	q := url.Values{}
+	for k, v := range cmd.Options {
+		q.Set(k, v)
+	}
+	opts, err := newTestOptions(q)
+	if err != nil {
+		p.publishError("invalid_request", err.Error())
+		return
+	}
+	if opts.IPVersion == IPVersionBoth {
+		p.publishError("invalid_request", "ip_version=both is not supported for MQTT commands")
+		return
+	}
+
+	var run func(ctx context.Context) error
+	switch cmd.Test {
+	case "ping":
+		run = func(ctx context.Context) error { _, err := speedTester.Ping(ctx, opts); return err }
+	case "download":
+		run = func(ctx context.Context) error {
+			_, err := speedTester.Download(ctx, &tester.TransferOptions{Options: *opts})
+			return err
+		}
+	case "upload":
+		run = func(ctx context.Context) error {
+			_, err := speedTester.Upload(ctx, &tester.TransferOptions{Options: *opts})
+			return err
+		}
+	case "run", "full":
+		run = func(ctx context.Context) error {
+			// One server for all three results
+			server, err := speedTester.FindServer(ctx, opts)
+			if err != nil {
+				return err
+			}
+			opts.Server = server
+			if _, err := speedTester.Ping(ctx, opts); err != nil {
+				return err
+			}
+			_, err = speedTester.Full(ctx, &tester.FullOptions{Options: *opts})
+			return err
+		}
+	default:
+		p.publishError("invalid_request", fmt.Sprintf("invalid test %q (use ping, download, upload or run)", cmd.Test))
+		return
+	}
+
+	if !p.running.CompareAndSwap(false, true) {
+		p.publishError("busy", "a test is already running")
+		return
+	}
+
+	// Handlers must not block the paho client
+	go func() {
+		defer p.running.Store(false)
+		slog.Info("mqtt command: starting test", "test", cmd.Test)
+		ctx, cancel := context.WithTimeout(context.Background(), mqttTestTimeout)
+		defer cancel()
+		if err := run(tester.WithRequester(ctx, "mqtt "+msg.Topic())); err != nil {
+			slog.Warn("mqtt command failed", "test", cmd.Test, "error", err)
+			p.publishError(tester.ErrorType(err), err.Error())
+		}
+	}()
+}
+
+// publishError publishes a failed command to <prefix>/error (not retained)
+func (p *mqttPublisher) publishError(errType, message string) {
+	p.enqueue(p.cfg.topic("error"), ErrorResponse{Error: errType, Message: message}, false)
+}
+
+// ==================== Home Assistant Discovery ====================
+
+// discoveryConfigs returns the retained discovery payloads keyed by topic
+func (p *mqttPublisher) discoveryConfigs() map[string]interface{} {
+	cfg := p.cfg
+	device := map[string]interface{}{
+		"identifiers":  []string{cfg.NodeID},
+		"name":         "GO-Speedtest",
+		"manufacturer": "GO-Speedtest",
+		"model":        "speedtest-go v1.7.10",
+		"sw_version":   Version,
+	}
+
+	sensor := func(object, name, topic, template, unit, deviceClass, icon string) (string, interface{}) {
+		config := map[string]interface{}{
+			"name":               name,
+			"unique_id":          cfg.NodeID + "_" + object,
+			"object_id":          cfg.NodeID + "_" + object,
+			"state_topic":        cfg.topic(topic),
+			"value_template":     template,
+			"availability_topic": cfg.topic("status"),
+			"device":             device,
+		}
+		if unit != "" {
+			config["unit_of_measurement"] = unit
+			config["state_class"] = "measurement"
+		}
+		if deviceClass != "" {
+			config["device_class"] = deviceClass
+		}
+		if icon != "" {
+			config["icon"] = icon
+		}
+		return cfg.DiscoveryPrefix + "/sensor/" + cfg.NodeID + "/" + object + "/config", config
+	}
+
+	configs := map[string]interface{}{}
+	add := func(topic string, config interface{}) { configs[topic] = config }
+	add(sensor("latency", "Latency", "ping", "{{ value_json.latency_ms }}", "ms", "duration", "mdi:timer-outline"))
+	add(sensor("download", "Download", "download", "{{ value_json.speed_mbps | round(2) }}", "Mbit/s", "data_rate", "mdi:download"))
+	add(sensor("upload", "Upload", "upload", "{{ value_json.speed_mbps | round(2) }}", "Mbit/s", "data_rate", "mdi:upload"))
+	add(sensor("server", "Server", "download", "{{ value_json.sponsor }} - {{ value_json.location }}", "", "", "mdi:server-network"))
+	add(sensor("public_ip", "Public IP", "download", "{{ value_json.client.ip if value_json.client else '' }}", "", "", "mdi:ip-network"))
+
+	// Button to trigger a full test from Home Assistant
+	configs[cfg.DiscoveryPrefix+"/button/"+cfg.NodeID+"/run/config"] = map[string]interface{}{
+		"name":               "Run speedtest",
+		"unique_id":          cfg.NodeID + "_run",
+		"object_id":          cfg.NodeID + "_run",
+		"command_topic":      cfg.topic("command"),
+		"payload_press":      "run",
+		"availability_topic": cfg.topic("status"),
+		"device":             device,
+		"icon":               "mdi:speedometer",
+	}
+	return configs
+}
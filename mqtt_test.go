@@ -0,0 +1,512 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-speedtest/api"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// ==================== Fake MQTT Client ====================
+
+// fakeToken is an already completed token
+type fakeToken struct{}
+
+func (fakeToken) Wait() bool                     { return true }
+func (fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (fakeToken) Error() error                   { return nil }
+func (fakeToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// fakePublish is one recorded Publish call
+type fakePublish struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  []byte
+}
+
+// fakeClient records publishes and subscriptions; other mqtt.Client
+// methods are not used by the publisher and panic
+type fakeClient struct {
+	mqtt.Client
+	mu            sync.Mutex
+	published     []fakePublish
+	subscriptions map[string]mqtt.MessageHandler
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var data []byte
+	switch p := payload.(type) {
+	case string:
+		data = []byte(p)
+	case []byte:
+		data = p
+	}
+	c.published = append(c.published, fakePublish{topic, qos, retained, data})
+	return fakeToken{}
+}
+
+func (c *fakeClient) Subscribe(topic string, _ byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = map[string]mqtt.MessageHandler{}
+	}
+	c.subscriptions[topic] = callback
+	return fakeToken{}
+}
+
+// fakeMessage is an incoming command
+type fakeMessage struct {
+	mqtt.Message
+	topic   string
+	payload string
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return []byte(m.payload) }
+
+// testPublisherConfig publishes under st/ with discovery for node1
+func testPublisherConfig() *mqttConfig {
+	return &mqttConfig{
+		TopicPrefix:     "st",
+		Discovery:       true,
+		DiscoveryPrefix: "homeassistant",
+		NodeID:          "node1",
+	}
+}
+
+// testPublisher returns a publisher with a fake client that is not started
+func testPublisher() (*mqttPublisher, *fakeClient) {
+	client := &fakeClient{}
+	return &mqttPublisher{
+		cfg:    testPublisherConfig(),
+		client: client,
+		queue:  make(chan mqttMessage, mqttQueueSize),
+	}, client
+}
+
+// drain returns the queued messages without publishing them
+func drain(p *mqttPublisher) []mqttMessage {
+	var msgs []mqttMessage
+	for {
+		select {
+		case msg := <-p.queue:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+// ==================== Command Topic ====================
+
+func TestOnCommandRejectsInvalidPayloads(t *testing.T) {
+	for _, tc := range []struct {
+		payload string
+		message string
+	}{
+		{`{"test": "ping"`, "invalid command"},
+		{`{"test": 1}`, "invalid command"},
+		{"speed", `invalid test "speed"`},
+		{"", `invalid test ""`},
+		{`{"test": "reboot"}`, `invalid test "reboot"`},
+		{`{"test": "ping", "options": {"ip_version": "5"}}`, "invalid ip_version"},
+		{`{"test": "ping", "options": {"lat": "1"}}`, "both lat and lon are required"},
+		{`{"test": "download", "options": {"ip_version": "both"}}`, "ip_version=both is not supported"},
+	} {
+		p, _ := testPublisher()
+		p.onCommand(nil, fakeMessage{topic: "st/command", payload: tc.payload})
+
+		msgs := drain(p)
+		if len(msgs) != 1 {
+			t.Errorf("payload %q: %d messages queued, want 1 error", tc.payload, len(msgs))
+			continue
+		}
+		var body ErrorResponse
+		if err := json.Unmarshal(msgs[0].payload, &body); err != nil {
+			t.Fatalf("payload %q: error body: %v", tc.payload, err)
+		}
+		if msgs[0].topic != "st/error" || msgs[0].retained {
+			t.Errorf("payload %q: published to %s retained=%v, want st/error not retained", tc.payload, msgs[0].topic, msgs[0].retained)
+		}
+		if body.Error != "invalid_request" || !strings.Contains(body.Message, tc.message) {
+			t.Errorf("payload %q: got %s %q, want invalid_request containing %q", tc.payload, body.Error, body.Message, tc.message)
+		}
+		if p.running.Load() {
+			t.Errorf("payload %q: rejected command marked a test as running", tc.payload)
+		}
+	}
+}
+
+// A valid command while a test runs is refused as busy, which also shows
+// the payload parsed without starting a test
+func TestOnCommandAcceptsValidPayloads(t *testing.T) {
+	for _, payload := range []string{
+		"ping", " download\n", "upload", "run", "full",
+		`{"test": "ping"}`,
+		`{"test": "run", "options": {"ip_version": "4", "lat": "-6.2", "lon": "106.8"}}`,
+	} {
+		p, _ := testPublisher()
+		p.running.Store(true)
+		p.onCommand(nil, fakeMessage{topic: "st/command", payload: payload})
+
+		msgs := drain(p)
+		if len(msgs) != 1 {
+			t.Fatalf("payload %q: %d messages queued, want 1", payload, len(msgs))
+		}
+		var body ErrorResponse
+		json.Unmarshal(msgs[0].payload, &body)
+		if body.Error != "busy" {
+			t.Errorf("payload %q: got %s %q, want busy", payload, body.Error, body.Message)
+		}
+	}
+}
+
+// ==================== Result Routing ====================
+
+func TestPublishResultRouting(t *testing.T) {
+	p, client := testPublisher()
+	p.publishResult(&api.PingResponse{TestID: "p1", Latency: 12.5})
+	p.publishResult(&api.DownloadResponse{TransferResult: api.TransferResult{TestID: "d1", SpeedMbps: 95.1}})
+	p.publishResult(&api.UploadResponse{TransferResult: api.TransferResult{TestID: "u1", SpeedMbps: 20.4}})
+	p.publishResult(&api.ClientInfo{IP: "192.0.2.1"}) // not a result: ignored
+	p.publishError("busy", "a test is already running")
+	close(p.queue)
+	p.run()
+
+	want := []struct {
+		topic    string
+		retained bool
+		field    string
+		value    interface{}
+	}{
+		{"st/ping", true, "test_id", "p1"},
+		{"st/download", true, "test_id", "d1"},
+		{"st/upload", true, "test_id", "u1"},
+		{"st/error", false, "error", "busy"},
+	}
+	if len(client.published) != len(want) {
+		t.Fatalf("%d messages published, want %d", len(client.published), len(want))
+	}
+	for i, w := range want {
+		got := client.published[i]
+		if got.topic != w.topic || got.retained != w.retained || got.qos != 1 {
+			t.Errorf("message %d: topic %s retained=%v qos=%d, want %s retained=%v qos=1",
+				i, got.topic, got.retained, got.qos, w.topic, w.retained)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(got.payload, &body); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if body[w.field] != w.value {
+			t.Errorf("message %d: %s = %v, want %v", i, w.field, body[w.field], w.value)
+		}
+	}
+}
+
+// ==================== Home Assistant Discovery ====================
+
+// Discovery must point Home Assistant at topics and JSON fields the
+// publisher actually produces
+func TestDiscoveryConfigs(t *testing.T) {
+	p, _ := testPublisher()
+	client := &api.ClientInfo{IP: "192.0.2.1"}
+	transfer := api.TransferResult{SpeedMbps: 95.1, Sponsor: "My ISP", Location: "Jakarta", Client: client}
+	results := map[string]interface{}{}
+	for _, result := range []interface{}{
+		&api.PingResponse{Latency: 12.5, Sponsor: "My ISP", Location: "Jakarta", Client: client},
+		&api.DownloadResponse{TransferResult: transfer},
+		&api.UploadResponse{TransferResult: transfer},
+	} {
+		p.publishResult(result)
+		msg := drain(p)[0]
+		var body map[string]interface{}
+		json.Unmarshal(msg.payload, &body)
+		results[msg.topic] = body
+	}
+	fieldRef := regexp.MustCompile(`value_json\.([a-z_.]+)`)
+
+	uniqueIDs := map[string]bool{}
+	var buttons int
+	for topic, payload := range p.discoveryConfigs() {
+		data, _ := json.Marshal(payload)
+		var config map[string]interface{}
+		json.Unmarshal(data, &config)
+
+		id, _ := config["unique_id"].(string)
+		if id == "" || uniqueIDs[id] {
+			t.Errorf("%s: unique_id %q missing or duplicated", topic, id)
+		}
+		uniqueIDs[id] = true
+		if config["availability_topic"] != "st/status" {
+			t.Errorf("%s: availability_topic %v, want the status topic st/status", topic, config["availability_topic"])
+		}
+		device, _ := config["device"].(map[string]interface{})
+		if ids, _ := device["identifiers"].([]interface{}); len(ids) == 0 || ids[0] != "node1" {
+			t.Errorf("%s: device identifiers %v, want node1", topic, device["identifiers"])
+		}
+
+		switch {
+		case strings.HasPrefix(topic, "homeassistant/sensor/node1/"):
+			stateTopic, _ := config["state_topic"].(string)
+			result, ok := results[stateTopic].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s: state_topic %q is not a result topic", topic, stateTopic)
+				continue
+			}
+			template, _ := config["value_template"].(string)
+			refs := fieldRef.FindAllStringSubmatch(template, -1)
+			if len(refs) == 0 {
+				t.Errorf("%s: value_template %q reads no result field", topic, template)
+			}
+			for _, ref := range refs {
+				var v interface{} = result
+				for _, key := range strings.Split(ref[1], ".") {
+					m, _ := v.(map[string]interface{})
+					v = m[key]
+				}
+				if v == nil {
+					t.Errorf("%s: value_template reads %s, which is not in the %s payload", topic, ref[1], stateTopic)
+				}
+			}
+		case strings.HasPrefix(topic, "homeassistant/button/node1/"):
+			buttons++
+			if config["command_topic"] != "st/command" {
+				t.Errorf("%s: command_topic %v, want st/command", topic, config["command_topic"])
+			}
+			// The press payload must be a valid command (refused as busy, not invalid)
+			press, _ := config["payload_press"].(string)
+			p.running.Store(true)
+			p.onCommand(nil, fakeMessage{topic: "st/command", payload: press})
+			p.running.Store(false)
+			if msgs := drain(p); len(msgs) != 1 || !strings.Contains(string(msgs[0].payload), `"busy"`) {
+				t.Errorf("%s: payload_press %q is not an accepted command", topic, press)
+			}
+		default:
+			t.Errorf("unexpected discovery topic %s", topic)
+		}
+	}
+	if buttons != 1 {
+		t.Errorf("%d buttons, want 1", buttons)
+	}
+}
+
+func TestOnConnect(t *testing.T) {
+	for _, discovery := range []bool{true, false} {
+		p, client := testPublisher()
+		p.cfg.Discovery = discovery
+		p.onConnect(client)
+
+		if _, ok := client.subscriptions["st/command"]; !ok {
+			t.Errorf("discovery=%v: not subscribed to st/command", discovery)
+		}
+		var status *fakePublish
+		configs := 0
+		for i, msg := range client.published {
+			if !msg.retained {
+				t.Errorf("discovery=%v: %s published without retain", discovery, msg.topic)
+			}
+			switch {
+			case msg.topic == "st/status":
+				status = &client.published[i]
+			case strings.HasPrefix(msg.topic, "homeassistant/"):
+				configs++
+				if !json.Valid(msg.payload) {
+					t.Errorf("%s: payload is not JSON", msg.topic)
+				}
+			}
+		}
+		if status == nil || string(status.payload) != "online" {
+			t.Errorf("discovery=%v: st/status online not published", discovery)
+		}
+		if want := len(p.discoveryConfigs()); discovery && configs != want {
+			t.Errorf("discovery=%v: %d discovery configs published, want %d", discovery, configs, want)
+		} else if !discovery && configs != 0 {
+			t.Errorf("discovery=false: %d discovery configs published", configs)
+		}
+	}
+}
+
+// ==================== Broker ====================
+
+// startBroker runs an in-process MQTT broker on addr (port 0 picks a free one)
+// and returns it with the address it listens on and a stop function
+func startBroker(t *testing.T, addr string) (*mochi.Server, string, func()) {
+	t.Helper()
+	if strings.HasSuffix(addr, ":0") {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr = l.Addr().String()
+		l.Close()
+	}
+	broker := mochi.New(&mochi.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	broker.AddHook(new(auth.AllowHook), nil)
+	if err := broker.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})); err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	stop := sync.OnceFunc(func() { broker.Close() })
+	t.Cleanup(stop)
+	return broker, addr, stop
+}
+
+// brokerMessage is a message received by a test subscriber
+type brokerMessage struct {
+	topic    string
+	payload  string
+	qos      byte
+	retained bool
+}
+
+// brokerSubscriber connects a test client to addr and subscribes to filter at QoS 1
+func brokerSubscriber(t *testing.T, addr, id, filter string) (mqtt.Client, chan brokerMessage) {
+	t.Helper()
+	received := make(chan brokerMessage, 100)
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker("tcp://" + addr).SetClientID(id).SetAutoReconnect(false))
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("subscriber connect: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(0) })
+	token := client.Subscribe(filter, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		received <- brokerMessage{msg.Topic(), string(msg.Payload()), msg.Qos(), msg.Retained()}
+	})
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("subscribe %s: %v", filter, token.Error())
+	}
+	return client, received
+}
+
+// waitMessage returns the first received message on topic
+func waitMessage(t *testing.T, received chan brokerMessage, topic, payload string) brokerMessage {
+	t.Helper()
+	timeout := time.After(15 * time.Second)
+	for {
+		select {
+		case msg := <-received:
+			if msg.topic == topic && (payload == "" || msg.payload == payload) {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s %s", topic, payload)
+		}
+	}
+}
+
+// brokerPublisher starts a publisher connected to addr, as startMQTT does
+// without the speedTester hook
+func brokerPublisher(t *testing.T, addr string) *mqttPublisher {
+	t.Helper()
+	cfg := *testPublisherConfig()
+	cfg.Broker = "tcp://" + addr
+	cfg.ClientID = "go-speedtest-test"
+	p := newMQTTPublisher(&cfg)
+	if token := p.client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("publisher connect: %v", token.Error())
+	}
+	go p.run()
+	t.Cleanup(func() {
+		close(p.queue)
+		p.client.Disconnect(0)
+	})
+	return p
+}
+
+func TestBrokerRetainAndQoS(t *testing.T) {
+	_, addr, _ := startBroker(t, "127.0.0.1:0")
+	_, live := brokerSubscriber(t, addr, "live", "st/#")
+	p := brokerPublisher(t, addr)
+
+	p.publishResult(&api.PingResponse{TestID: "p1", Latency: 12.5})
+	p.publishResult(&api.DownloadResponse{TransferResult: api.TransferResult{TestID: "d1", SpeedMbps: 95.1}})
+	p.publishError("busy", "a test is already running")
+	for _, topic := range []string{"st/ping", "st/download", "st/error"} {
+		if msg := waitMessage(t, live, topic, ""); msg.qos != 1 {
+			t.Errorf("%s delivered with QoS %d, want 1", topic, msg.qos)
+		}
+	}
+
+	// A client connecting later (Home Assistant after a restart) gets the
+	// retained state, but not old errors
+	_, late := brokerSubscriber(t, addr, "late", "#")
+	want := map[string]bool{"st/status": true, "st/ping": true, "st/download": true}
+	for topic := range p.discoveryConfigs() {
+		want[topic] = true
+	}
+	timeout := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case msg := <-late:
+			if !msg.retained {
+				t.Errorf("%s: delivered live, want retained", msg.topic)
+			}
+			if msg.topic == "st/error" {
+				t.Errorf("st/error was retained: %s", msg.payload)
+			}
+			if msg.topic == "st/status" && msg.payload != "online" {
+				t.Errorf("st/status %q, want online", msg.payload)
+			}
+			delete(want, msg.topic)
+		case <-timeout:
+			t.Fatalf("retained messages missing: %v", want)
+		}
+	}
+	select {
+	case msg := <-late:
+		t.Errorf("unexpected retained message %s %s", msg.topic, msg.payload)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBrokerWillAndReconnect(t *testing.T) {
+	broker, addr, stop := startBroker(t, "127.0.0.1:0")
+	_, status := brokerSubscriber(t, addr, "watch", "st/status")
+	brokerPublisher(t, addr)
+	waitMessage(t, status, "st/status", "online")
+
+	// Dropped connection: the broker publishes the will, the client reconnects
+	client, ok := broker.Clients.Get("go-speedtest-test")
+	if !ok {
+		t.Fatal("publisher not connected to the broker")
+	}
+	if will := client.Properties.Will; will.TopicName != "st/status" || !will.Retain || will.Qos != 1 {
+		t.Errorf("will %s retain=%v qos=%d, want st/status retained at QoS 1", will.TopicName, will.Retain, will.Qos)
+	}
+	client.Stop(errors.New("connection dropped by test"))
+	waitMessage(t, status, "st/status", "offline")
+	waitMessage(t, status, "st/status", "online")
+
+	// Broker restart without persistence: status and the command
+	// subscription are restored on the new broker
+	stop()
+	_, addr, _ = startBroker(t, addr)
+	commander, received := brokerSubscriber(t, addr, "commander", "st/#")
+	waitMessage(t, received, "st/status", "online")
+	commander.Publish("st/command", 1, false, "reboot").WaitTimeout(5 * time.Second)
+	if msg := waitMessage(t, received, "st/error", ""); !strings.Contains(msg.payload, "invalid_request") {
+		t.Errorf("command after reconnect: got %s, want invalid_request", msg.payload)
+	}
+}
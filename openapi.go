@@ -0,0 +1,454 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-speedtest/api"
+)
+
+// ==================== Routes & OpenAPI ====================
+
+// apiParam is a query (or path) parameter of an API route
+type apiParam struct {
+	name        string
+	schema      map[string]interface{}
+	description string
+}
+
+// apiRoute is one documented API endpoint. serve() registers routes from
+// apiRoutes and /openapi.json is generated from the same table and the Go
+// response types, so the spec cannot drift from the handlers.
+type apiRoute struct {
+	path        string
+	summary     string
+	description string
+	handler     http.HandlerFunc
+	params      []apiParam
+	pathParams  []apiParam  // {name} segments of path
+	response    interface{} // zero value of the 200 response type
+	dualStack   bool        // ip_version=both returns DualStackResult
+	stream      bool        // text/event-stream of StreamEvent
+	v2          bool        // api.Envelope responses, api.Problem errors
+	errors      []int       // documented error statuses (ErrorResponse, or Problem for v2)
+}
+
+func stringParam(enum ...string) map[string]interface{} {
+	s := map[string]interface{}{"type": "string"}
+	if len(enum) > 0 {
+		s["enum"] = enum
+	}
+	return s
+}
+
+var (
+	numberParam  = map[string]interface{}{"type": "number", "format": "double"}
+	integerParam = map[string]interface{}{"type": "integer"}
+)
+
+// testParams are the query parameters shared by test endpoints (see newTestOptions)
+var testParams = []apiParam{
+	{"server_id", stringParam(), "Ookla server ID (default: closest server)"},
+	{"lat", numberParam, "Assumed client latitude (with lon)"},
+	{"lon", numberParam, "Assumed client longitude (with lat)"},
+	{"city", stringParam(), "Assumed client city from the embedded cities.csv"},
+	{"ip_version", stringParam(IPVersion4, IPVersion6, IPVersionBoth), "Force address family; both runs IPv4 then IPv6"},
+	{"link", stringParam(), "Named link from SPEEDTEST_LINKS"},
+	{"proxy", stringParam(), "Proxy name from SPEEDTEST_PROXIES (URLs only with SPEEDTEST_ALLOW_PROXY_URLS=true)"},
+}
+
+var streamParams = append(append([]apiParam{}, testParams...),
+	apiParam{"duration", integerParam, "Test duration in seconds (default 10, max 30)"})
+
+var v2Params = append(append([]apiParam{}, testParams...),
+	apiParam{"timeout", integerParam, "Request timeout in seconds (default 120, max 300); 504 when exceeded"})
+
+var historyIDParam = []apiParam{{"id", stringParam(), "Test ID (test_id of the result)"}}
+
+// apiRoutes returns the documented API routes
+func apiRoutes() []apiRoute {
+	testErrors := []int{http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusServiceUnavailable}
+	return []apiRoute{
+		{
+			path: "/", summary: "Server status",
+			handler: statusHandler, response: StatusResponse{},
+			errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/healthz", summary: "Liveness probe",
+			description: "200 as long as the process serves requests.",
+			handler:     healthzHandler, response: api.HealthResponse{},
+			errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/readyz", summary: "Readiness probe",
+			description: "Reports cached catalogue freshness (fails when older than READY_CATALOGUE_MAX_AGE; " +
+				"refetched in the background), history storage and test slot usage. 503 with the same body when a check fails.",
+			handler: readyzHandler, response: api.ReadyResponse{},
+			errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/ping", summary: "Latency test",
+			description: "Pings the selected Ookla server.",
+			handler:     speedtestPingHandler, params: testParams, response: PingResponse{},
+			dualStack: true, errors: testErrors,
+		},
+		{
+			path: "/speedtest/download", summary: "Download speed test",
+			description: "Runs ping and a full download test; takes 5-15 seconds.",
+			handler:     speedtestDownloadHandler, params: testParams, response: DownloadResponse{},
+			dualStack: true, errors: testErrors,
+		},
+		{
+			path: "/speedtest/upload", summary: "Upload speed test",
+			description: "Runs ping and a full upload test; takes 5-15 seconds.",
+			handler:     speedtestUploadHandler, params: testParams, response: UploadResponse{},
+			dualStack: true, errors: testErrors,
+		},
+		{
+			path: "/speedtest/servers", summary: "Closest servers",
+			description: "Lists the 10 closest Ookla servers.",
+			handler:     speedtestServersHandler, params: testParams, response: ServersResponse{},
+			errors: testErrors,
+		},
+		{
+			path: "/speedtest/whoami", summary: "Public IP / ISP of this host",
+			handler: speedtestWhoamiHandler,
+			params: append(append([]apiParam{}, testParams...),
+				apiParam{"refresh", stringParam("1"), "Bypass the 10 minute cache"}),
+			response: ClientInfo{}, errors: testErrors,
+		},
+		{
+			path: "/speedtest/links", summary: "Configured links (multi-WAN)",
+			handler: speedtestLinksHandler, response: LinksResponse{},
+			errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/download/stream", summary: "Download speed test (SSE)",
+			description: "Streams start, progress and complete (or error) events. " +
+				"With ip_version=both: one start/complete per family, then a summary event.",
+			handler: speedtestDownloadStreamHandler, params: streamParams, response: StreamEvent{},
+			stream: true, errors: []int{http.StatusBadRequest, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/upload/stream", summary: "Upload speed test (SSE)",
+			description: "Streams start, progress and complete (or error) events. " +
+				"With ip_version=both: one start/complete per family, then a summary event.",
+			handler: speedtestUploadStreamHandler, params: streamParams, response: StreamEvent{},
+			stream: true, errors: []int{http.StatusBadRequest, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/history", summary: "Completed tests",
+			description: "Newest first; the last HISTORY_SIZE (default 100) tests from every API.",
+			handler:     speedtestHistoryHandler,
+			params:      []apiParam{{"limit", integerParam, "Maximum number of entries"}},
+			response:    api.HistoryResponse{},
+			errors:      []int{http.StatusBadRequest, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/history/{id}", summary: "One completed test",
+			handler: speedtestHistoryEntryHandler, pathParams: historyIDParam, response: api.HistoryEntry{},
+			errors: []int{http.StatusNotFound, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/history/{id}/samples", summary: "Sample series of a test",
+			description: "Timestamp, instantaneous Mbps, cumulative bytes and loaded latency per progress sample.",
+			handler:     speedtestHistorySamplesHandler, pathParams: historyIDParam, response: api.SamplesResponse{},
+			errors: []int{http.StatusNotFound, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/history/{id}/replay", summary: "Replay a recorded test (SSE)",
+			description: "Re-emits the start, progress and complete events of a recorded download or upload " +
+				"with the original timing, in the same format as /speedtest/download/stream.",
+			handler:    speedtestHistoryReplayHandler,
+			pathParams: historyIDParam,
+			params:     []apiParam{{"speed", numberParam, "Playback speed multiplier (default 1, max 100)"}},
+			response:   StreamEvent{}, stream: true,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/live", summary: "Watch all running tests (SSE)",
+			description: "Streams start, progress, complete and error events of every running ping, download and upload (pings have no progress), " +
+				"whichever API started it. Each event carries test_id and phase. " +
+				"Running tests are sent first; the stream stays open with keep-alive comments.",
+			handler:  speedtestLiveHandler,
+			response: StreamEvent{}, stream: true,
+			errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/speedtest/live/{id}", summary: "Watch one running test (SSE)",
+			description: "Like /speedtest/live for a single test; the stream ends with its complete or error event.",
+			handler:     speedtestLiveHandler,
+			pathParams:  []apiParam{{"id", stringParam(), "Test ID (test_id of a start event)"}},
+			response:    StreamEvent{}, stream: true,
+			errors: []int{http.StatusNotFound, http.StatusMethodNotAllowed},
+		},
+		{
+			path: "/openapi.json", summary: "This OpenAPI document",
+			handler: openAPIHandler, response: map[string]interface{}{},
+		},
+
+		// v2: uniform envelope and problem+json errors (see v2.go)
+		{
+			path: api.V2Prefix + "/status", summary: "Server status",
+			handler: v2StatusHandler, response: StatusResponse{},
+			v2: true, errors: []int{http.StatusMethodNotAllowed},
+		},
+		{
+			path: api.V2Prefix + "/ping", summary: "Latency test",
+			handler: v2PingHandler, params: v2Params, response: PingResponse{},
+			dualStack: true, v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/download", summary: "Download speed test",
+			handler: v2DownloadHandler, params: v2Params, response: DownloadResponse{},
+			dualStack: true, v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/upload", summary: "Upload speed test",
+			handler: v2UploadHandler, params: v2Params, response: UploadResponse{},
+			dualStack: true, v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/full", summary: "Download + upload test",
+			description: "Runs download then upload against one server. ip_version=both is not supported.",
+			handler:     v2FullHandler, params: v2Params, response: RunResult{},
+			v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/servers", summary: "Closest servers",
+			handler: v2ServersHandler, params: v2Params, response: ServersResponse{},
+			v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/whoami", summary: "Public IP / ISP of this host",
+			handler: v2WhoamiHandler,
+			params: append(append([]apiParam{}, v2Params...),
+				apiParam{"refresh", stringParam("1"), "Bypass the 10 minute cache"}),
+			response: ClientInfo{}, v2: true, errors: v2TestErrors,
+		},
+		{
+			path: api.V2Prefix + "/links", summary: "Configured links (multi-WAN)",
+			handler: v2LinksHandler, response: LinksResponse{},
+			v2: true, errors: []int{http.StatusMethodNotAllowed},
+		},
+	}
+}
+
+// v2TestErrors are the documented problem statuses of v2 test endpoints
+var v2TestErrors = []int{
+	http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusConflict,
+	http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+}
+
+// ==================== OpenAPI Generation ====================
+
+// schemaBuilder converts Go types to OpenAPI schemas, collecting named structs as components
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+// schema returns the schema for t, a $ref for named structs
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = nil // placeholder, StreamEvent refers to itself
+			b.components[t.Name()] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{} // interface{}: any value
+	}
+}
+
+// structSchema builds an object schema from exported fields and their json tags.
+// Fields of embedded structs are flattened, as encoding/json does.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// buildOpenAPI generates the OpenAPI 3 document from apiRoutes
+func buildOpenAPI() map[string]interface{} {
+	b := &schemaBuilder{components: map[string]interface{}{}}
+	errorSchema := b.schema(reflect.TypeOf(ErrorResponse{}))
+	problemSchema := b.schema(reflect.TypeOf(api.Problem{}))
+	metaSchema := b.schema(reflect.TypeOf(api.Meta{}))
+
+	paths := map[string]interface{}{}
+	for _, route := range apiRoutes() {
+		var params []interface{}
+		for _, p := range route.pathParams {
+			params = append(params, map[string]interface{}{
+				"name":        p.name,
+				"in":          "path",
+				"required":    true,
+				"schema":      p.schema,
+				"description": p.description,
+			})
+		}
+		for _, p := range route.params {
+			params = append(params, map[string]interface{}{
+				"name":        p.name,
+				"in":          "query",
+				"required":    false,
+				"schema":      p.schema,
+				"description": p.description,
+			})
+		}
+
+		result := b.schema(reflect.TypeOf(route.response))
+		var content map[string]interface{}
+		switch {
+		case route.stream:
+			content = map[string]interface{}{
+				"text/event-stream": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":        "string",
+						"description": "Events as \"data: {json}\" lines; each JSON payload is a StreamEvent",
+					},
+					"x-event-schema": result,
+				},
+			}
+		case route.v2:
+			if route.dualStack {
+				result = map[string]interface{}{
+					"oneOf": []interface{}{result, b.schema(reflect.TypeOf(api.DualStackData{}))},
+				}
+			}
+			content = map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"data": result, "meta": metaSchema},
+				"required":   []string{"data", "meta"},
+			}}}
+		case route.dualStack:
+			result = map[string]interface{}{
+				"oneOf": []interface{}{result, b.schema(reflect.TypeOf(DualStackResult{}))},
+			}
+			fallthrough
+		default:
+			content = map[string]interface{}{"application/json": map[string]interface{}{"schema": result}}
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK", "content": content},
+		}
+		for _, status := range route.errors {
+			description := http.StatusText(status)
+			media := map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}}
+			if route.v2 {
+				media = map[string]interface{}{api.ProblemContentType: map[string]interface{}{"schema": problemSchema}}
+			} else if status == http.StatusServiceUnavailable && route.dualStack {
+				description += " (with ip_version=both: DualStackResult when both families failed)"
+			}
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": description,
+				"content":     media,
+			}
+		}
+
+		op := map[string]interface{}{
+			"operationId": operationID(route.path),
+			"summary":     route.summary,
+			"responses":   responses,
+		}
+		if route.description != "" {
+			op["description"] = route.description
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		paths[route.path] = map[string]interface{}{"get": op}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "GO-Speedtest API",
+			"version":     Version,
+			"description": "Internet speed tests against Ookla servers using speedtest-go.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": b.components},
+	}
+}
+
+// operationID derives an operation ID from a path ("/speedtest/download/stream" -> "speedtestDownloadStream",
+// "/speedtest/history/{id}" -> "speedtestHistoryById")
+func operationID(path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' })
+	if len(parts) == 0 {
+		return "status"
+	}
+	id := parts[0]
+	for _, p := range parts[1:] {
+		if strings.HasPrefix(p, "{") {
+			name := strings.Trim(p, "{}")
+			p = "by" + strings.ToUpper(name[:1]) + name[1:]
+		}
+		id += strings.ToUpper(p[:1]) + p[1:]
+	}
+	return id
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]interface{}
+)
+
+// openAPIHandler - GET /openapi.json
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+	openAPIOnce.Do(func() { openAPIDoc = buildOpenAPI() })
+	writeJSON(w, http.StatusOK, openAPIDoc)
+}
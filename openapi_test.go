@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-speedtest/api"
+)
+
+// undocumentedRoutes are registered on purpose without an OpenAPI entry
+var undocumentedRoutes = map[string]bool{
+	api.V2Prefix + "/":         true, // v2 problem+json 404 fallback
+	"/speedtest/ws":            true, // WebSocket
+	"/admin/tests":             true, // admin API
+	"/admin/tests/{id}/cancel": true,
+	"/ui/":                     true, // dashboard files
+	"/ui":                      true,
+	"/docs":                    true,
+}
+
+// testMux builds the server routes the way serve does, with admin routes enabled
+func testMux() *routeMux {
+	return newMux(newMiddlewareStack(cors, false), &adminConfig{token: "test"})
+}
+
+// specPaths returns the /openapi.json paths with their documented methods
+func specPaths(t *testing.T) map[string]map[string]bool {
+	t.Helper()
+	paths, ok := buildOpenAPI()["paths"].(map[string]interface{})
+	if !ok || len(paths) == 0 {
+		t.Fatal("openapi document has no paths")
+	}
+	result := map[string]map[string]bool{}
+	for path, item := range paths {
+		ops, ok := item.(map[string]interface{})
+		if !ok {
+			t.Fatalf("path %s: unexpected item %T", path, item)
+		}
+		result[path] = map[string]bool{}
+		for method := range ops {
+			result[path][strings.ToUpper(method)] = true
+		}
+	}
+	return result
+}
+
+// concretePath fills {param} segments with a dummy value
+func concretePath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			segments[i] = "0123456789abcdef"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestRegisteredRoutesAreDocumented(t *testing.T) {
+	spec := specPaths(t)
+	for _, pattern := range testMux().patterns {
+		if undocumentedRoutes[pattern] {
+			continue
+		}
+		if _, ok := spec[pattern]; !ok {
+			t.Errorf("route %s is registered but missing from /openapi.json", pattern)
+		}
+	}
+}
+
+func TestDocumentedRoutesResolve(t *testing.T) {
+	mux := testMux()
+	for path, methods := range specPaths(t) {
+		for method := range methods {
+			req := httptest.NewRequest(method, concretePath(path), nil)
+			if _, pattern := mux.Handler(req); pattern != path {
+				t.Errorf("%s %s resolves to pattern %q, want %q", method, path, pattern, path)
+			}
+		}
+	}
+}
+
+// Methods missing from the spec must be rejected by the handler, so the
+// documented methods are the only ones that work
+func TestUndocumentedMethodsRejected(t *testing.T) {
+	mux := testMux()
+	for path, methods := range specPaths(t) {
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if methods[method] {
+				continue
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(method, concretePath(path), nil))
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s %s: status %d, want %d (method not in /openapi.json)", method, path, rec.Code, http.StatusMethodNotAllowed)
+			}
+		}
+	}
+}
+
+func TestUnknownPathNotFound(t *testing.T) {
+	mux := testMux()
+	for _, path := range []string{"/speedtest/nope", api.V2Prefix + "/nope"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ==================== Prometheus Remote-Write ====================
+
+// remoteWriteSink pushes points as Prometheus remote-write 1.0
+// (snappy-compressed protobuf WriteRequest). Works with Prometheus,
+// VictoriaMetrics, Mimir and Thanos receive.
+type remoteWriteSink struct {
+	url         string
+	username    string
+	password    string
+	bearerToken string
+	client      *http.Client
+}
+
+func newRemoteWriteSink(writeURL, username, password, bearerToken string) (*remoteWriteSink, error) {
+	u, err := url.Parse(writeURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return &remoteWriteSink{
+		url:         writeURL,
+		username:    username,
+		password:    password,
+		bearerToken: bearerToken,
+		client:      &http.Client{},
+	}, nil
+}
+
+func (s *remoteWriteSink) Name() string { return "remote-write" }
+
+func (s *remoteWriteSink) Write(ctx context.Context, points []metricPoint) error {
+	body := snappy.Encode(nil, encodeWriteRequest(points))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	switch {
+	case s.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+s.bearerToken)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	if !retryableStatus(resp.StatusCode) {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// promSeries is one time series: sorted labels and samples in time order
+type promSeries struct {
+	labels  [][2]string
+	samples []promSample
+}
+
+type promSample struct {
+	value     float64
+	timestamp int64 // ms
+}
+
+// promSeriesList turns each point field into <measurement>_<field>{tags} series
+func promSeriesList(points []metricPoint) []*promSeries {
+	byKey := map[string]*promSeries{}
+	var order []string
+	for _, p := range points {
+		for field, value := range p.fields {
+			labels := [][2]string{{"__name__", p.measurement + "_" + field}}
+			for k, v := range p.tags {
+				labels = append(labels, [2]string{k, v})
+			}
+			sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+
+			var key strings.Builder
+			for _, l := range labels {
+				key.WriteString(l[0] + "\xff" + l[1] + "\xff")
+			}
+			series, ok := byKey[key.String()]
+			if !ok {
+				series = &promSeries{labels: labels}
+				byKey[key.String()] = series
+				order = append(order, key.String())
+			}
+			series.samples = append(series.samples, promSample{value: value, timestamp: p.time.UnixMilli()})
+		}
+	}
+
+	list := make([]*promSeries, 0, len(order))
+	for _, key := range order {
+		series := byKey[key]
+		sort.SliceStable(series.samples, func(i, j int) bool {
+			return series.samples[i].timestamp < series.samples[j].timestamp
+		})
+		list = append(list, series)
+	}
+	return list
+}
+
+// encodeWriteRequest encodes prometheus.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(points []metricPoint) []byte {
+	var out []byte
+	for _, series := range promSeriesList(points) {
+		var ts []byte
+		for _, l := range series.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l[0])
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l[1])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		for _, s := range series.samples {
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+		}
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, ts)
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeWriteRequest parses a prometheus.WriteRequest (see encodeWriteRequest)
+func decodeWriteRequest(t *testing.T, data []byte) []*promSeries {
+	t.Helper()
+
+	// fields calls fn for each field of a message
+	fields := func(msg []byte, fn func(num protowire.Number, typ protowire.Type, value []byte)) {
+		for len(msg) > 0 {
+			num, typ, n := protowire.ConsumeTag(msg)
+			if n < 0 {
+				t.Fatalf("bad tag: %v", protowire.ParseError(n))
+			}
+			msg = msg[n:]
+			m := protowire.ConsumeFieldValue(num, typ, msg)
+			if m < 0 {
+				t.Fatalf("bad field %d: %v", num, protowire.ParseError(m))
+			}
+			fn(num, typ, msg[:m])
+			msg = msg[m:]
+		}
+	}
+	bytesValue := func(value []byte) []byte {
+		v, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			t.Fatalf("bad bytes: %v", protowire.ParseError(n))
+		}
+		return v
+	}
+
+	var list []*promSeries
+	fields(data, func(num protowire.Number, typ protowire.Type, value []byte) {
+		if num != 1 || typ != protowire.BytesType {
+			t.Fatalf("WriteRequest: unexpected field %d type %d", num, typ)
+		}
+		series := &promSeries{}
+		fields(bytesValue(value), func(num protowire.Number, typ protowire.Type, value []byte) {
+			switch num {
+			case 1: // Label
+				var label [2]string
+				fields(bytesValue(value), func(num protowire.Number, _ protowire.Type, value []byte) {
+					label[num-1] = string(bytesValue(value))
+				})
+				series.labels = append(series.labels, label)
+			case 2: // Sample
+				var sample promSample
+				fields(bytesValue(value), func(num protowire.Number, _ protowire.Type, value []byte) {
+					switch num {
+					case 1:
+						bits, _ := protowire.ConsumeFixed64(value)
+						sample.value = math.Float64frombits(bits)
+					case 2:
+						ts, _ := protowire.ConsumeVarint(value)
+						sample.timestamp = int64(ts)
+					}
+				})
+				series.samples = append(series.samples, sample)
+			default:
+				t.Fatalf("TimeSeries: unexpected field %d", num)
+			}
+		})
+		list = append(list, series)
+	})
+	return list
+}
+
+// seriesName returns the __name__ label
+func seriesName(s *promSeries) string {
+	for _, l := range s.labels {
+		if l[0] == "__name__" {
+			return l[1]
+		}
+	}
+	return ""
+}
+
+func TestRemoteWriteRoundTrip(t *testing.T) {
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	download := func(speed float64, at time.Time) metricPoint {
+		return metricPoint{
+			measurement: "speedtest",
+			tags:        map[string]string{"test": "download", "server_id": "1234", "sponsor": "My ISP", "link": "wan1"},
+			fields:      map[string]float64{"speed_mbps": speed, "latency_ms": 10},
+			time:        at,
+		}
+	}
+	points := []metricPoint{
+		download(95.5, t0.Add(time.Minute)), // out of order: samples must be sorted
+		{
+			measurement: "speedtest",
+			tags:        map[string]string{"test": "ping", "server_id": "1234"},
+			fields:      map[string]float64{"latency_ms": 12.25},
+			time:        t0.Add(30 * time.Second),
+		},
+		download(80, t0),
+	}
+
+	sink, err := newRemoteWriteSink(srv.URL+"/api/v1/write", "user", "pass", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), points); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"Content-Type":                      "application/x-protobuf",
+		"Content-Encoding":                  "snappy",
+		"X-Prometheus-Remote-Write-Version": "0.1.0",
+	} {
+		if got := header.Get(key); got != want {
+			t.Errorf("%s: %q, want %q", key, got, want)
+		}
+	}
+	if user, pass, ok := (&http.Request{Header: header}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("basic auth %q/%q, want user/pass", user, pass)
+	}
+
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatalf("snappy: %v", err)
+	}
+	list := decodeWriteRequest(t, data)
+
+	// Same labels are grouped: 2 download series + 1 ping series
+	byName := map[string][]*promSeries{}
+	for _, s := range list {
+		if !sort.SliceIsSorted(s.labels, func(i, j int) bool { return s.labels[i][0] < s.labels[j][0] }) {
+			t.Errorf("labels not sorted: %v", s.labels)
+		}
+		if s.labels[0][0] != "__name__" {
+			t.Errorf("first label %q, want __name__", s.labels[0][0])
+		}
+		byName[seriesName(s)] = append(byName[seriesName(s)], s)
+	}
+	if len(list) != 3 || len(byName["speedtest_speed_mbps"]) != 1 || len(byName["speedtest_latency_ms"]) != 2 {
+		t.Fatalf("series %v, want speed_mbps{download} and latency_ms{download}, latency_ms{ping}", byName)
+	}
+
+	speed := byName["speedtest_speed_mbps"][0]
+	wantLabels := [][2]string{
+		{"__name__", "speedtest_speed_mbps"}, {"link", "wan1"}, {"server_id", "1234"},
+		{"sponsor", "My ISP"}, {"test", "download"},
+	}
+	if len(speed.labels) != len(wantLabels) {
+		t.Fatalf("labels %v, want %v", speed.labels, wantLabels)
+	}
+	for i := range wantLabels {
+		if speed.labels[i] != wantLabels[i] {
+			t.Errorf("label %d: %v, want %v", i, speed.labels[i], wantLabels[i])
+		}
+	}
+	wantSamples := []promSample{{80, t0.UnixMilli()}, {95.5, t0.Add(time.Minute).UnixMilli()}}
+	if len(speed.samples) != 2 || speed.samples[0] != wantSamples[0] || speed.samples[1] != wantSamples[1] {
+		t.Errorf("samples %v, want %v (grouped, oldest first)", speed.samples, wantSamples)
+	}
+
+	for _, s := range byName["speedtest_latency_ms"] {
+		var test string
+		for _, l := range s.labels {
+			if l[0] == "test" {
+				test = l[1]
+			}
+		}
+		switch {
+		case test == "ping" && (len(s.samples) != 1 || s.samples[0].value != 12.25):
+			t.Errorf("ping latency samples %v, want one 12.25", s.samples)
+		case test == "download" && len(s.samples) != 2:
+			t.Errorf("download latency samples %v, want 2", s.samples)
+		}
+	}
+}
+
+func TestRemoteWriteAuthAndStatus(t *testing.T) {
+	var auth string
+	status := http.StatusTooManyRequests
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		http.Error(w, "nope", status)
+	}))
+	defer srv.Close()
+
+	// A bearer token wins over basic auth
+	sink, _ := newRemoteWriteSink(srv.URL, "user", "pass", "tok")
+	points := []metricPoint{testPoint("ping", 1)}
+
+	var permanent *permanentError
+	err := sink.Write(context.Background(), points)
+	if err == nil || errors.As(err, &permanent) {
+		t.Errorf("HTTP 429: error %v, want a retryable error", err)
+	}
+	if auth != "Bearer tok" {
+		t.Errorf("Authorization %q, want Bearer tok", auth)
+	}
+
+	status = http.StatusBadRequest
+	err = sink.Write(context.Background(), points)
+	if !errors.As(err, &permanent) || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("HTTP 400: error %v, want a permanentError with the body", err)
+	}
+}
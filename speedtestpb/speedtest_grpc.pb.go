@@ -0,0 +1,313 @@
+// gRPC API of GO-Speedtest. Mirrors the HTTP API (see package api);
+// regenerate the Go code with protoc-gen-go and protoc-gen-go-grpc:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative speedtestpb/speedtest.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: speedtestpb/speedtest.proto
+
+package speedtestpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Speedtest_Ping_FullMethodName        = "/speedtest.v1.Speedtest/Ping"
+	Speedtest_Download_FullMethodName    = "/speedtest.v1.Speedtest/Download"
+	Speedtest_Upload_FullMethodName      = "/speedtest.v1.Speedtest/Upload"
+	Speedtest_Full_FullMethodName        = "/speedtest.v1.Speedtest/Full"
+	Speedtest_ListServers_FullMethodName = "/speedtest.v1.Speedtest/ListServers"
+)
+
+// SpeedtestClient is the client API for Speedtest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Speedtest runs tests against Ookla servers.
+//
+// Errors use standard status codes: INVALID_ARGUMENT for bad options or
+// server_id, NOT_FOUND for an unknown server, UNAVAILABLE when the server
+// catalogue or a test phase failed, DEADLINE_EXCEEDED and CANCELLED from
+// the call context.
+type SpeedtestClient interface {
+	// Ping measures latency to the selected server.
+	Ping(ctx context.Context, in *TestOptions, opts ...grpc.CallOption) (*PingResult, error)
+	// Download streams a start event, progress samples and the result.
+	Download(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error)
+	// Upload streams a start event, progress samples and the result.
+	Upload(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error)
+	// Full runs download then upload against one server, streaming both phases.
+	Full(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error)
+	// ListServers returns the closest servers.
+	ListServers(ctx context.Context, in *ListServersRequest, opts ...grpc.CallOption) (*ListServersResponse, error)
+}
+
+type speedtestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpeedtestClient(cc grpc.ClientConnInterface) SpeedtestClient {
+	return &speedtestClient{cc}
+}
+
+func (c *speedtestClient) Ping(ctx context.Context, in *TestOptions, opts ...grpc.CallOption) (*PingResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResult)
+	err := c.cc.Invoke(ctx, Speedtest_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speedtestClient) Download(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Speedtest_ServiceDesc.Streams[0], Speedtest_Download_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TransferRequest, TransferEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_DownloadClient = grpc.ServerStreamingClient[TransferEvent]
+
+func (c *speedtestClient) Upload(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Speedtest_ServiceDesc.Streams[1], Speedtest_Upload_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TransferRequest, TransferEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_UploadClient = grpc.ServerStreamingClient[TransferEvent]
+
+func (c *speedtestClient) Full(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Speedtest_ServiceDesc.Streams[2], Speedtest_Full_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TransferRequest, TransferEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_FullClient = grpc.ServerStreamingClient[TransferEvent]
+
+func (c *speedtestClient) ListServers(ctx context.Context, in *ListServersRequest, opts ...grpc.CallOption) (*ListServersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListServersResponse)
+	err := c.cc.Invoke(ctx, Speedtest_ListServers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SpeedtestServer is the server API for Speedtest service.
+// All implementations must embed UnimplementedSpeedtestServer
+// for forward compatibility.
+//
+// Speedtest runs tests against Ookla servers.
+//
+// Errors use standard status codes: INVALID_ARGUMENT for bad options or
+// server_id, NOT_FOUND for an unknown server, UNAVAILABLE when the server
+// catalogue or a test phase failed, DEADLINE_EXCEEDED and CANCELLED from
+// the call context.
+type SpeedtestServer interface {
+	// Ping measures latency to the selected server.
+	Ping(context.Context, *TestOptions) (*PingResult, error)
+	// Download streams a start event, progress samples and the result.
+	Download(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error
+	// Upload streams a start event, progress samples and the result.
+	Upload(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error
+	// Full runs download then upload against one server, streaming both phases.
+	Full(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error
+	// ListServers returns the closest servers.
+	ListServers(context.Context, *ListServersRequest) (*ListServersResponse, error)
+	mustEmbedUnimplementedSpeedtestServer()
+}
+
+// UnimplementedSpeedtestServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpeedtestServer struct{}
+
+func (UnimplementedSpeedtestServer) Ping(context.Context, *TestOptions) (*PingResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedSpeedtestServer) Download(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedSpeedtestServer) Upload(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Upload not implemented")
+}
+func (UnimplementedSpeedtestServer) Full(*TransferRequest, grpc.ServerStreamingServer[TransferEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Full not implemented")
+}
+func (UnimplementedSpeedtestServer) ListServers(context.Context, *ListServersRequest) (*ListServersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServers not implemented")
+}
+func (UnimplementedSpeedtestServer) mustEmbedUnimplementedSpeedtestServer() {}
+func (UnimplementedSpeedtestServer) testEmbeddedByValue()                   {}
+
+// UnsafeSpeedtestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpeedtestServer will
+// result in compilation errors.
+type UnsafeSpeedtestServer interface {
+	mustEmbedUnimplementedSpeedtestServer()
+}
+
+func RegisterSpeedtestServer(s grpc.ServiceRegistrar, srv SpeedtestServer) {
+	// If the following call pancis, it indicates UnimplementedSpeedtestServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Speedtest_ServiceDesc, srv)
+}
+
+func _Speedtest_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestOptions)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeedtestServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Speedtest_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeedtestServer).Ping(ctx, req.(*TestOptions))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Speedtest_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpeedtestServer).Download(m, &grpc.GenericServerStream[TransferRequest, TransferEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_DownloadServer = grpc.ServerStreamingServer[TransferEvent]
+
+func _Speedtest_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpeedtestServer).Upload(m, &grpc.GenericServerStream[TransferRequest, TransferEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_UploadServer = grpc.ServerStreamingServer[TransferEvent]
+
+func _Speedtest_Full_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SpeedtestServer).Full(m, &grpc.GenericServerStream[TransferRequest, TransferEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Speedtest_FullServer = grpc.ServerStreamingServer[TransferEvent]
+
+func _Speedtest_ListServers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeedtestServer).ListServers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Speedtest_ListServers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeedtestServer).ListServers(ctx, req.(*ListServersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Speedtest_ServiceDesc is the grpc.ServiceDesc for Speedtest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Speedtest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "speedtest.v1.Speedtest",
+	HandlerType: (*SpeedtestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Speedtest_Ping_Handler,
+		},
+		{
+			MethodName: "ListServers",
+			Handler:    _Speedtest_ListServers_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Download",
+			Handler:       _Speedtest_Download_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Upload",
+			Handler:       _Speedtest_Upload_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Full",
+			Handler:       _Speedtest_Full_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "speedtestpb/speedtest.proto",
+}
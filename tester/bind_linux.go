@@ -0,0 +1,25 @@
+//go:build linux
+
+package tester
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// bindToDeviceControl binds sockets to a network interface (SO_BINDTODEVICE)
+func bindToDeviceControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+		})
+		if err != nil {
+			return err
+		}
+		if sockErr != nil {
+			return fmt.Errorf("bind to interface %s: %w", iface, sockErr)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package tester
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// bindToDeviceControl is only supported on Linux; use a source IP link elsewhere
+func bindToDeviceControl(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		return fmt.Errorf("binding to interface %s is only supported on Linux", iface)
+	}
+}
@@ -0,0 +1,165 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ==================== Coordinator ====================
+
+// Active test states (see ActiveTest)
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+)
+
+// ErrCancelled is the cause of a test stopped with Cancel
+var ErrCancelled = errors.New("test cancelled by admin")
+
+// ActiveTest is a snapshot of a queued or running test (see ActiveTests)
+type ActiveTest struct {
+	ID        string
+	State     string // "queued" or "running"
+	Phase     string // "ping", "download" or "upload"
+	Requester string // see WithRequester
+	ServerID  string // empty until the server is selected
+	Sponsor   string
+	Location  string
+	SpeedMbps float64 // latest progress sample (download/upload)
+	Queued    time.Time
+	Started   time.Time // zero while queued
+}
+
+// activeTest is a registered test with its cancel function
+type activeTest struct {
+	ActiveTest
+	cancel context.CancelCauseFunc
+	slot   bool // holds a MaxConcurrent slot
+}
+
+type (
+	requesterKey struct{}
+	testIDKey    struct{}
+)
+
+// WithRequester tags tests run with ctx with who asked for them,
+// e.g. "http 203.0.113.5:51234"
+func WithRequester(ctx context.Context, requester string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// Requester returns the requester set with WithRequester
+func Requester(ctx context.Context) string {
+	requester, _ := ctx.Value(requesterKey{}).(string)
+	return requester
+}
+
+// TestID returns the ID of the test running with ctx ("" outside a test).
+// Log lines written with the test context carry it.
+func TestID(ctx context.Context) string {
+	id, _ := ctx.Value(testIDKey{}).(string)
+	return id
+}
+
+// begin registers a test, waits for a slot when MaxConcurrent is set and
+// returns the test context, cancelled by Cancel. end must be called once
+// begin succeeds.
+func (t *Tester) begin(ctx context.Context, phase string) (*activeTest, context.Context, error) {
+	id := newTestID()
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, testIDKey{}, id))
+	test := &activeTest{
+		ActiveTest: ActiveTest{
+			ID:        id,
+			State:     StateQueued,
+			Phase:     phase,
+			Requester: Requester(ctx),
+			Queued:    time.Now(),
+		},
+		cancel: cancel,
+	}
+
+	t.activeMu.Lock()
+	t.active[test.ID] = test
+	slots := t.slots
+	if slots == nil && t.MaxConcurrent > 0 {
+		t.slots = make(chan struct{}, t.MaxConcurrent)
+		slots = t.slots
+	}
+	t.activeMu.Unlock()
+
+	if slots != nil {
+		_, span := tracer.Start(ctx, "queue wait")
+		select {
+		case slots <- struct{}{}:
+			test.slot = true
+			span.End()
+		case <-ctx.Done():
+			err := cancelled(ctx, ctx.Err())
+			recordError(span, err)
+			span.End()
+			t.end(test)
+			return nil, nil, err
+		}
+	}
+
+	t.updateActive(test, func(a *ActiveTest) {
+		a.State = StateRunning
+		a.Started = time.Now()
+	})
+	return test, ctx, nil
+}
+
+// end unregisters test and frees its slot
+func (t *Tester) end(test *activeTest) {
+	t.activeMu.Lock()
+	delete(t.active, test.ID)
+	slots := t.slots
+	t.activeMu.Unlock()
+
+	if test.slot {
+		<-slots
+	}
+	test.cancel(nil)
+}
+
+// updateActive applies fn to test under the registry lock
+func (t *Tester) updateActive(test *activeTest, fn func(*ActiveTest)) {
+	t.activeMu.Lock()
+	defer t.activeMu.Unlock()
+	fn(&test.ActiveTest)
+}
+
+// ActiveTests returns the queued and running tests, oldest first
+func (t *Tester) ActiveTests() []ActiveTest {
+	t.activeMu.Lock()
+	defer t.activeMu.Unlock()
+
+	tests := make([]ActiveTest, 0, len(t.active))
+	for _, test := range t.active {
+		tests = append(tests, test.ActiveTest)
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Queued.Before(tests[j].Queued) })
+	return tests
+}
+
+// Cancel stops the queued or running test with id; the test returns a
+// TestError wrapping ErrCancelled. Reports whether the test was found.
+func (t *Tester) Cancel(id string) bool {
+	t.activeMu.Lock()
+	test, ok := t.active[id]
+	t.activeMu.Unlock()
+	if ok {
+		test.cancel(ErrCancelled)
+	}
+	return ok
+}
+
+// cancelled turns err into a "cancelled" TestError when ctx was stopped by Cancel
+func cancelled(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrCancelled) {
+		return &TestError{"cancelled", ErrCancelled}
+	}
+	return err
+}
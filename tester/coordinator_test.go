@@ -0,0 +1,162 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// beginResult is the outcome of a begin call made in the background
+type beginResult struct {
+	test *activeTest
+	ctx  context.Context
+	err  error
+}
+
+func beginAsync(tr *Tester, ctx context.Context, phase string) chan beginResult {
+	done := make(chan beginResult, 1)
+	go func() {
+		test, ctx, err := tr.begin(ctx, phase)
+		done <- beginResult{test, ctx, err}
+	}()
+	return done
+}
+
+// waitActive waits until tr has n registered tests and returns them
+func waitActive(t *testing.T, tr *Tester, n int) []ActiveTest {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tests := tr.ActiveTests()
+		if len(tests) == n {
+			return tests
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d active tests, want %d", len(tests), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBeginUnlimited(t *testing.T) {
+	tr := New()
+	var tests []*activeTest
+	for i := 0; i < 3; i++ {
+		test, _, err := tr.begin(context.Background(), PhasePing)
+		if err != nil {
+			t.Fatalf("begin %d: %v", i, err)
+		}
+		tests = append(tests, test)
+	}
+	for _, a := range tr.ActiveTests() {
+		if a.State != StateRunning {
+			t.Errorf("test %s is %s without MaxConcurrent, want running", a.ID, a.State)
+		}
+	}
+	for _, test := range tests {
+		tr.end(test)
+	}
+	if n := len(tr.ActiveTests()); n != 0 {
+		t.Errorf("%d tests left after end", n)
+	}
+}
+
+func TestBeginQueuesBeyondMaxConcurrent(t *testing.T) {
+	tr := New()
+	tr.MaxConcurrent = 1
+
+	first, ctx, err := tr.begin(WithRequester(context.Background(), "http 192.0.2.1:1000"), PhaseDownload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if TestID(ctx) != first.ID {
+		t.Errorf("TestID(ctx) = %q, want %q", TestID(ctx), first.ID)
+	}
+	second := beginAsync(tr, WithRequester(context.Background(), "grpc 192.0.2.2:2000"), PhaseUpload)
+
+	tests := waitActive(t, tr, 2)
+	if tests[0].ID != first.ID || tests[0].State != StateRunning || tests[0].Requester != "http 192.0.2.1:1000" {
+		t.Errorf("first test %+v, want running and listed first", tests[0])
+	}
+	if tests[1].State != StateQueued || tests[1].Phase != PhaseUpload || !tests[1].Started.IsZero() {
+		t.Errorf("second test %+v, want queued", tests[1])
+	}
+	select {
+	case <-second:
+		t.Fatal("second test started while the slot was taken")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Ending the first test hands its slot over
+	tr.end(first)
+	r := <-second
+	if r.err != nil {
+		t.Fatalf("queued test: %v", r.err)
+	}
+	if tests := tr.ActiveTests(); len(tests) != 1 || tests[0].State != StateRunning {
+		t.Errorf("active tests %+v, want the second one running", tests)
+	}
+	tr.end(r.test)
+}
+
+func TestCancelQueuedTest(t *testing.T) {
+	tr := New()
+	tr.MaxConcurrent = 1
+	running, _, _ := tr.begin(context.Background(), PhasePing)
+	queued := beginAsync(tr, context.Background(), PhasePing)
+	tests := waitActive(t, tr, 2)
+
+	if !tr.Cancel(tests[1].ID) {
+		t.Fatal("Cancel did not find the queued test")
+	}
+	r := <-queued
+	if !errors.Is(r.err, ErrCancelled) || ErrorType(r.err) != "cancelled" {
+		t.Errorf("cancelled queued test: %v (%s), want a cancelled TestError", r.err, ErrorType(r.err))
+	}
+	waitActive(t, tr, 1)
+
+	// The cancelled test never held the slot: the next one starts after the running one
+	tr.end(running)
+	next, _, err := tr.begin(context.Background(), PhasePing)
+	if err != nil {
+		t.Fatalf("begin after cancel: %v", err)
+	}
+	tr.end(next)
+}
+
+func TestCancelRunningTest(t *testing.T) {
+	tr := New()
+	test, ctx, err := tr.begin(context.Background(), PhaseDownload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Cancel("unknown") {
+		t.Error("Cancel of an unknown ID reported true")
+	}
+	if !tr.Cancel(test.ID) {
+		t.Fatal("Cancel did not find the running test")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("test context not cancelled")
+	}
+	if err := cancelled(ctx, ctx.Err()); ErrorType(err) != "cancelled" {
+		t.Errorf("cancelled running test: %v, want a cancelled TestError", err)
+	}
+	tr.end(test)
+	if tr.Cancel(test.ID) {
+		t.Error("Cancel found a test after end")
+	}
+
+	// A client going away is not an admin cancel
+	clientCtx, cancel := context.WithCancel(context.Background())
+	test, ctx, _ = tr.begin(clientCtx, PhaseDownload)
+	cancel()
+	<-ctx.Done()
+	if err := cancelled(ctx, ctx.Err()); !errors.Is(err, context.Canceled) || ErrorType(err) == "cancelled" {
+		t.Errorf("client cancel: %v, want context.Canceled", err)
+	}
+	tr.end(test)
+}
@@ -0,0 +1,77 @@
+package tester
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-speedtest/api"
+
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// ==================== Dual-Stack Testing ====================
+
+// DualStackResult represents side-by-side IPv4 and IPv6 results.
+// Each family holds the normal test response, or an ErrorResponse when that family failed.
+type DualStackResult struct {
+	IPVersion string      `json:"ip_version"` // always "both"
+	IPv4      interface{} `json:"ipv4"`
+	IPv6      interface{} `json:"ipv6"`
+	Timestamp int64       `json:"timestamp"`
+
+	failed int // number of families that failed
+}
+
+// AllFailed reports whether both families failed
+func (r *DualStackResult) AllFailed() bool {
+	return r.failed == 2
+}
+
+// DualStackKey returns the JSON key for an address family ("ipv4"/"ipv6")
+func DualStackKey(version string) string {
+	return "ipv" + version
+}
+
+// BindServer returns a copy of server that runs over a client built from opts
+func BindServer(server *speedtest.Server, opts *Options) *speedtest.Server {
+	s := *server
+	s.Context = newSpeedtestClient(opts)
+	return &s
+}
+
+// DualStack runs a test over IPv4 then IPv6 against the same server.
+// run receives per-family options with Server bound to that family.
+// Per-family failures are reported inline as ErrorResponse.
+func (t *Tester) DualStack(ctx context.Context, opts *Options,
+	run func(ctx context.Context, opts *Options) (interface{}, error)) (*DualStackResult, error) {
+	server, err := t.FindServer(ctx, opts)
+	if err != nil {
+		return nil, proxyFailure(opts, err)
+	}
+
+	result := &DualStackResult{IPVersion: IPVersionBoth}
+	for _, version := range []string{IPVersion4, IPVersion6} {
+		familyOpts := opts.WithIPVersion(version)
+		familyOpts.Server = BindServer(server, familyOpts)
+
+		var value interface{}
+		response, err := run(ctx, familyOpts)
+		if err != nil {
+			slog.WarnContext(ctx, "dual-stack family failed", "ip_version", version, "error", err)
+			value = api.ErrorResponse{Error: ErrorType(err), Message: err.Error()}
+			result.failed++
+		} else {
+			value = response
+		}
+
+		if version == IPVersion4 {
+			result.IPv4 = value
+		} else {
+			result.IPv6 = value
+		}
+	}
+
+	result.Timestamp = time.Now().UnixMilli()
+	return result, nil
+}
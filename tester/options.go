@@ -0,0 +1,149 @@
+// Package tester runs speed tests against Ookla servers using speedtest-go.
+//
+// It is the engine behind the GO-Speedtest HTTP API and CLI, and can be
+// embedded in other Go programs:
+//
+//	t := tester.New()
+//	result, err := t.Download(ctx, &tester.TransferOptions{
+//		OnProgress: func(p tester.Progress) { fmt.Printf("%.2f Mbps\n", p.SpeedMbps) },
+//	})
+package tester
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"go-speedtest/api"
+
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// ==================== Options ====================
+
+// IP version values
+const (
+	IPVersion4    = "4"
+	IPVersion6    = "6"
+	IPVersionBoth = "both" // DualStack only
+)
+
+// Proxy is an upstream proxy that catalogue fetches and transfers go through
+type Proxy struct {
+	Name string   // Configured name, empty for ad-hoc URLs
+	URL  *url.URL // http://, https://, socks5:// or socks5h://, optional user:pass
+}
+
+// Identity returns the proxy name, or its URL with the password redacted
+func (p *Proxy) Identity() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.URL.Redacted()
+}
+
+// Options selects the server and egress path of a test; zero value = closest server, default route
+type Options struct {
+	ServerID  string               // Specific server, default closest
+	Location  *api.AssumedLocation // Client location for server distances, default IP geolocation
+	IPVersion string               // "4" or "6" to force an address family
+	Link      *api.Link            // Bind to a source IP / interface
+	Proxy     *Proxy               // Run through a proxy
+
+	// Server is an already selected server (from FindServer); skips selection
+	Server *speedtest.Server
+}
+
+// WithIPVersion returns a copy of opts forced to one address family
+func (o *Options) WithIPVersion(version string) *Options {
+	c := *o
+	c.IPVersion = version
+	return &c
+}
+
+// LinkName returns the link name, or "" for the default route
+func (o *Options) LinkName() string {
+	if o.Link == nil {
+		return ""
+	}
+	return o.Link.Name
+}
+
+// ProxyIdentity returns the proxy identity for results, or "" when direct
+func (o *Options) ProxyIdentity() string {
+	if o.Proxy == nil {
+		return ""
+	}
+	return o.Proxy.Identity()
+}
+
+// newSpeedtestClient creates a speedtest client configured from options
+func newSpeedtestClient(opts *Options) *speedtest.Speedtest {
+	uc := &speedtest.UserConfig{}
+	if opts.Location != nil {
+		uc.Location = speedtestLocation(opts.Location)
+	}
+	var familyControl, deviceControl func(network, address string, c syscall.RawConn) error
+	if opts.IPVersion == IPVersion4 || opts.IPVersion == IPVersion6 {
+		familyControl = ipFamilyControl(opts.IPVersion)
+	}
+	if opts.Link != nil {
+		uc.Source = opts.Link.SourceIP
+		if opts.Link.Interface != "" {
+			deviceControl = bindToDeviceControl(opts.Link.Interface)
+		}
+	}
+	uc.DialerControl = chainControl(familyControl, deviceControl)
+	if opts.Proxy != nil {
+		uc.Proxy = opts.Proxy.URL.String()
+	}
+
+	// Own http.Client per test: speedtest-go otherwise installs its
+	// transport on http.DefaultClient, shared by every concurrent test
+	return speedtest.New(speedtest.WithDoer(&http.Client{}), speedtest.WithUserConfig(uc))
+}
+
+// ==================== Dialer Control ====================
+
+// ipFamilyControl rejects sockets of the other address family, so the
+// dialer only connects over IPv4 or IPv6
+func ipFamilyControl(version string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address // ICMP dialer passes a bare IP
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil
+		}
+		isV4 := ip.To4() != nil
+		if (version == IPVersion4 && !isV4) || (version == IPVersion6 && isV4) {
+			return fmt.Errorf("address %s is not IPv%s", host, version)
+		}
+		return nil
+	}
+}
+
+// chainControl runs dialer control funcs in order, stopping at the first error
+func chainControl(fns ...func(network, address string, c syscall.RawConn) error) func(network, address string, c syscall.RawConn) error {
+	var active []func(network, address string, c syscall.RawConn) error
+	for _, fn := range fns {
+		if fn != nil {
+			active = append(active, fn)
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		for _, fn := range active {
+			if err := fn(network, address, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,231 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-speedtest/api"
+
+	"github.com/showwin/speedtest-go/speedtest"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ==================== Server Selection ====================
+
+// earthRadiusKm is the equatorial radius used by speedtest-go for distances
+const earthRadiusKm = 6378.137
+
+// Server selection errors, matched with errors.Is
+var (
+	ErrCatalogue       = errors.New("failed to fetch servers")
+	ErrInvalidServerID = errors.New("invalid server_id")
+	ErrServerNotFound  = errors.New("not found")
+	ErrNoServers       = errors.New("no available servers found")
+)
+
+// Servers fetches the server catalogue, sorted by distance from opts.Location
+func (t *Tester) Servers(ctx context.Context, opts *Options) (speedtest.Servers, error) {
+	ctx, span := tracer.Start(ctx, "catalogue fetch")
+	defer span.End()
+
+	client := newSpeedtestClient(opts)
+	servers, err := client.FetchServerListContext(ctx)
+	if t.defaultPath(opts) {
+		t.recordCatalogue(len(servers), err)
+	}
+	if err != nil {
+		recordError(span, err)
+		err = fmt.Errorf("%w: %w", ErrCatalogue, err)
+		if opts.Proxy != nil {
+			slog.WarnContext(ctx, "catalogue fetch failed", "proxy", opts.ProxyIdentity(), "error", err)
+		}
+		return nil, proxyFailure(opts, err)
+	}
+	applyLocation(servers, opts.Location)
+	span.SetAttributes(attribute.Int("speedtest.catalogue.servers", len(servers)))
+	return servers, nil
+}
+
+// CatalogueStatus describes the latest catalogue fetches (see Tester.Catalogue)
+type CatalogueStatus struct {
+	LastSuccess time.Time // zero until a fetch succeeds
+	LastAttempt time.Time
+	LastError   error // error of the last attempt, nil when it succeeded
+	Servers     int   // servers in the last successful fetch
+}
+
+// Catalogue reports when the server catalogue was last fetched with the
+// default options (Tester.Defaults), by any test or Servers call. Fetches
+// through another link, proxy, IP version or location are not counted,
+// nor are cancelled ones.
+func (t *Tester) Catalogue() CatalogueStatus {
+	t.catalogueMu.Lock()
+	defer t.catalogueMu.Unlock()
+	return t.catalogue
+}
+
+// defaultPath reports whether a catalogue fetch with opts goes the way a
+// request without test parameters would (see Tester.Defaults)
+func (t *Tester) defaultPath(opts *Options) bool {
+	if opts.IPVersion != "" || opts.Link != nil || opts.ProxyIdentity() != t.Defaults.ProxyIdentity() {
+		return false
+	}
+	a, b := opts.Location, t.Defaults.Location
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Lat == b.Lat && a.Lon == b.Lon
+}
+
+// recordCatalogue updates Catalogue after a fetch
+func (t *Tester) recordCatalogue(servers int, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	t.catalogueMu.Lock()
+	defer t.catalogueMu.Unlock()
+	now := time.Now()
+	t.catalogue.LastAttempt, t.catalogue.LastError = now, err
+	if err == nil {
+		t.catalogue.LastSuccess, t.catalogue.Servers = now, servers
+	}
+}
+
+// FindServer returns opts.Server, the server with opts.ServerID, or the closest server
+func (t *Tester) FindServer(ctx context.Context, opts *Options) (*speedtest.Server, error) {
+	if opts.Server != nil {
+		return opts.Server, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "server selection")
+	defer span.End()
+	server, err := t.findServer(ctx, opts)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(serverAttributes(server)...)
+	return server, nil
+}
+
+// findServer selects the server for FindServer from the catalogue
+func (t *Tester) findServer(ctx context.Context, opts *Options) (*speedtest.Server, error) {
+	// Validate server_id before the catalogue fetch
+	var id int
+	if opts.ServerID != "" {
+		var err error
+		if id, err = strconv.Atoi(opts.ServerID); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidServerID, err)
+		}
+	}
+
+	servers, err := t.Servers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if specific server ID requested
+	if opts.ServerID != "" {
+		targets, err := servers.FindServer([]int{id})
+		if err != nil || len(targets) == 0 {
+			return nil, fmt.Errorf("server with ID %s %w", opts.ServerID, ErrServerNotFound)
+		}
+
+		return targets[0], nil
+	}
+
+	// With location override, closest means shortest recomputed distance
+	if opts.Location != nil {
+		if server := nearestServer(servers); server != nil {
+			return server, nil
+		}
+		return nil, ErrNoServers
+	}
+
+	// FindServer with empty slice returns closest servers
+	targets, err := servers.FindServer([]int{})
+	if err != nil || len(targets) == 0 {
+		return nil, ErrNoServers
+	}
+
+	// Return the closest server (first in list)
+	return targets[0], nil
+}
+
+// ServerInfoList converts the closest limit servers to ServerInfo
+func ServerInfoList(servers speedtest.Servers, limit int) []api.ServerInfo {
+	if len(servers) < limit {
+		limit = len(servers)
+	}
+
+	var serverList []api.ServerInfo
+	for i := 0; i < limit; i++ {
+		s := servers[i]
+		serverList = append(serverList, api.ServerInfo{
+			ID:      s.ID,
+			Sponsor: s.Sponsor, Location: s.Name,
+			Host:     s.Host,
+			Country:  s.Country,
+			Distance: s.Distance,
+		})
+	}
+	return serverList
+}
+
+// ==================== Location ====================
+
+// speedtestLocation converts to the speedtest-go location type
+func speedtestLocation(l *api.AssumedLocation) *speedtest.Location {
+	return &speedtest.Location{Name: l.Name, CC: strings.ToLower(l.Country), Lat: l.Lat, Lon: l.Lon}
+}
+
+// distanceKm computes great-circle distance between two coordinates
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// applyLocation recomputes server distances from loc and re-sorts by distance
+func applyLocation(servers speedtest.Servers, loc *api.AssumedLocation) {
+	if loc == nil {
+		return
+	}
+	for _, s := range servers {
+		lat, errLat := strconv.ParseFloat(s.Lat, 64)
+		lon, errLon := strconv.ParseFloat(s.Lon, 64)
+		if errLat != nil || errLon != nil {
+			s.Distance = math.MaxFloat64
+			continue
+		}
+		s.Distance = distanceKm(loc.Lat, loc.Lon, lat, lon)
+	}
+	sort.SliceStable(servers, func(i, j int) bool {
+		return servers[i].Distance < servers[j].Distance
+	})
+}
+
+// nearestServer returns the closest reachable server after applyLocation
+func nearestServer(servers speedtest.Servers) *speedtest.Server {
+	for _, s := range servers {
+		if s.Latency != speedtest.PingTimeout {
+			return s
+		}
+	}
+	if len(servers) > 0 {
+		return servers[0]
+	}
+	return nil
+}
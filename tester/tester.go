@@ -0,0 +1,682 @@
+package tester
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go-speedtest/api"
+
+	"github.com/showwin/speedtest-go/speedtest"
+	"github.com/showwin/speedtest-go/speedtest/transport"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// ==================== Tester ====================
+
+// Test phases
+const (
+	PhasePing     = "ping"
+	PhaseDownload = "download"
+	PhaseUpload   = "upload"
+)
+
+// Tester runs ping, download and upload tests. Safe for concurrent use;
+// every test gets its own speedtest-go client.
+type Tester struct {
+	// SkipClientInfo disables the public IP/ISP lookup attached to results
+	SkipClientInfo bool
+	// MaxConcurrent queues tests beyond this many running at once (0 = unlimited).
+	// Set before the first test.
+	MaxConcurrent int
+	// Defaults are the options a request without test parameters gets
+	// (configured location and proxy); only catalogue fetches made with
+	// them update Catalogue. Set before the first test.
+	Defaults Options
+
+	clientInfoMu    sync.Mutex // guards clientInfo only, never held across a lookup
+	clientInfo      map[string]clientInfoEntry
+	clientInfoGroup singleflight.Group // in-flight lookups by clientInfoKey
+	fetchUserInfo   func(ctx context.Context, opts *Options) (*api.ClientInfo, error)
+
+	hooksMu      sync.RWMutex
+	hooks        []func(result interface{})
+	sampleHooks  []func(Sample)
+	startHooks   []func(Start)
+	failureHooks []func(Failure)
+
+	activeMu sync.Mutex
+	active   map[string]*activeTest // queued and running tests by ID
+	slots    chan struct{}          // MaxConcurrent semaphore, nil when unlimited
+
+	catalogueMu sync.Mutex
+	catalogue   CatalogueStatus
+}
+
+// New returns a Tester
+func New() *Tester {
+	return &Tester{
+		clientInfo:    make(map[string]clientInfoEntry),
+		fetchUserInfo: fetchSpeedtestUserInfo,
+		active:        make(map[string]*activeTest),
+	}
+}
+
+// OnResult registers fn to be called with every completed test result:
+// *api.PingResponse, *api.DownloadResponse or *api.UploadResponse.
+// fn runs on the test goroutine and must not block.
+func (t *Tester) OnResult(fn func(result interface{})) {
+	t.hooksMu.Lock()
+	defer t.hooksMu.Unlock()
+	t.hooks = append(t.hooks, fn)
+}
+
+// OnSample registers fn to be called with every download/upload progress sample
+// of every test. fn runs on the test goroutine and must not block.
+func (t *Tester) OnSample(fn func(Sample)) {
+	t.hooksMu.Lock()
+	defer t.hooksMu.Unlock()
+	t.sampleHooks = append(t.sampleHooks, fn)
+}
+
+// OnStart registers fn to be called when a test starts: a ping after server
+// selection, a download or upload when it starts transferring (after server
+// selection and ping). Every start is followed
+// by one result (OnResult) or one Failure (OnFailure) with the same TestID.
+// fn runs on the test goroutine and must not block.
+func (t *Tester) OnStart(fn func(Start)) {
+	t.hooksMu.Lock()
+	defer t.hooksMu.Unlock()
+	t.startHooks = append(t.startHooks, fn)
+}
+
+// OnFailure registers fn to be called when a started test (see OnStart)
+// fails or is cancelled. fn runs on the test goroutine and must not block.
+func (t *Tester) OnFailure(fn func(Failure)) {
+	t.hooksMu.Lock()
+	defer t.hooksMu.Unlock()
+	t.failureHooks = append(t.failureHooks, fn)
+}
+
+// notifyStart passes s to the OnStart hooks
+func (t *Tester) notifyStart(s Start) {
+	t.hooksMu.RLock()
+	defer t.hooksMu.RUnlock()
+	for _, fn := range t.startHooks {
+		fn(s)
+	}
+}
+
+// notifyFailure passes f to the OnFailure hooks
+func (t *Tester) notifyFailure(f Failure) {
+	t.hooksMu.RLock()
+	defer t.hooksMu.RUnlock()
+	for _, fn := range t.failureHooks {
+		fn(f)
+	}
+}
+
+// notifySample passes s to the OnSample hooks
+func (t *Tester) notifySample(s Sample) {
+	t.hooksMu.RLock()
+	defer t.hooksMu.RUnlock()
+	for _, fn := range t.sampleHooks {
+		fn(s)
+	}
+}
+
+// notify passes result to the OnResult hooks
+func (t *Tester) notify(result interface{}) {
+	t.hooksMu.RLock()
+	defer t.hooksMu.RUnlock()
+	for _, fn := range t.hooks {
+		fn(result)
+	}
+}
+
+// TestError is a failed test phase; Type is the API error type (e.g. "download_failed")
+type TestError struct {
+	Type string
+	Err  error
+}
+
+func (e *TestError) Error() string { return e.Err.Error() }
+func (e *TestError) Unwrap() error { return e.Err }
+
+// ErrorType returns the API error type of err: the TestError type, a code
+// telling clients the test never started (catalogue or server selection
+// failed, see api.APIError.RetrySafe), or "server_error"
+func ErrorType(err error) string {
+	var te *TestError
+	switch {
+	case errors.As(err, &te):
+		return te.Type
+	case errors.Is(err, ErrCatalogue):
+		return api.CodeCatalogueUnavailable
+	case errors.Is(err, ErrNoServers):
+		return api.CodeNoServers
+	}
+	return "server_error"
+}
+
+// failed logs a failed test phase and returns err (a "cancelled" TestError
+// after Cancel, a "proxy_failed" one for network errors through a proxy)
+func failed(ctx context.Context, phase string, opts *Options, err error) error {
+	err = cancelled(ctx, err)
+	recordError(trace.SpanFromContext(ctx), err)
+	slog.WarnContext(ctx, phase+" failed", "error", err)
+	return proxyFailure(opts, err)
+}
+
+// ErrProxy replaces the network error of a test run through a proxy, so
+// API clients cannot use proxies to probe hosts reachable from the server
+var ErrProxy = errors.New("proxy connection failed")
+
+// proxyFailure hides the dial details of errors through opts.Proxy; the
+// caller logs the original error
+func proxyFailure(opts *Options, err error) error {
+	if opts.Proxy == nil || err == nil {
+		return err
+	}
+	for _, keep := range []error{ErrProxy, ErrCancelled, ErrInvalidServerID, ErrServerNotFound, context.Canceled, context.DeadlineExceeded} {
+		if errors.Is(err, keep) {
+			return err
+		}
+	}
+	return &TestError{"proxy_failed", ErrProxy}
+}
+
+// Progress is a realtime speed sample of a download or upload
+type Progress struct {
+	Phase     string // "download" or "upload"
+	SpeedMbps float64
+	Elapsed   time.Duration
+
+	Bytes         int64         // Cumulative bytes transferred in this phase
+	LoadedLatency time.Duration // Latest latency probe during the transfer (0 until the first probe)
+}
+
+// Sample is a progress sample with the test it belongs to (see OnSample)
+type Sample struct {
+	Progress
+	TestID    string // Same as the result's TestID
+	Time      time.Time
+	ServerID  string
+	Sponsor   string
+	IPVersion string
+	Link      string
+	Proxy     string
+}
+
+// Start is a test that began (see OnStart); LatencyMs is 0 for a ping,
+// whose latency comes with the result
+type Start struct {
+	TestID    string
+	Phase     string
+	Time      time.Time
+	Server    *speedtest.Server
+	LatencyMs float64
+	IPVersion string
+	Link      string
+	Proxy     string
+
+	AssumedLocation *api.AssumedLocation
+}
+
+// Failure is a started test that did not complete (see OnFailure)
+type Failure struct {
+	TestID string
+	Phase  string
+	Time   time.Time
+	Err    error // context.Canceled when the caller went away
+}
+
+// TransferOptions configures a download or upload test
+type TransferOptions struct {
+	Options
+
+	// Duration stops the test early and reports the last sampled speed (0 = run to completion)
+	Duration time.Duration
+
+	// OnStart is called after the ping, before the transfer starts
+	OnStart func(server *speedtest.Server, latencyMs float64)
+	// OnProgress is called for every speed sample
+	OnProgress func(Progress)
+}
+
+// FullOptions configures a full (download + upload) test
+type FullOptions struct {
+	Options
+
+	Duration     time.Duration // per phase, see TransferOptions
+	SkipDownload bool
+	SkipUpload   bool
+
+	OnStart    func(server *speedtest.Server, latencyMs float64) // once per phase
+	OnProgress func(Progress)
+}
+
+// FullResult is the result of Full; skipped phases are nil
+type FullResult struct {
+	Download *api.DownloadResponse `json:"download,omitempty"`
+	Upload   *api.UploadResponse   `json:"upload,omitempty"`
+}
+
+// mbps converts speedtest-go byte rate (bytes per second) to Mbps
+func mbps(rate speedtest.ByteRate) float64 {
+	return float64(rate) / 1_000_000 * 8
+}
+
+// ==================== Test Runners ====================
+
+// Ping measures latency to the selected server
+func (t *Tester) Ping(ctx context.Context, opts *Options) (*api.PingResponse, error) {
+	ctx, span := startTest(ctx, PhasePing, opts)
+	defer span.End()
+
+	test, testCtx, err := t.begin(ctx, PhasePing)
+	if err != nil {
+		return nil, failed(ctx, PhasePing, opts, err)
+	}
+	defer t.end(test)
+	ctx = testCtx
+	span.SetAttributes(attribute.String("speedtest.test.id", test.ID))
+	t.prefetchClientInfo(ctx, opts)
+
+	server, err := t.FindServer(ctx, opts)
+	if err != nil {
+		return nil, failed(ctx, PhasePing, opts, err)
+	}
+	t.updateActive(test, activeServer(server))
+	span.SetAttributes(serverAttributes(server)...)
+	t.notifyStart(Start{
+		TestID:    test.ID,
+		Phase:     PhasePing,
+		Time:      time.Now(),
+		Server:    server,
+		IPVersion: opts.IPVersion,
+		Link:      opts.LinkName(),
+		Proxy:     opts.ProxyIdentity(),
+
+		AssumedLocation: opts.Location,
+	})
+
+	if err := pingServer(ctx, server); err != nil {
+		err = failed(ctx, PhasePing, opts, err)
+		t.notifyFailure(Failure{TestID: test.ID, Phase: PhasePing, Time: time.Now(), Err: err})
+		return nil, err
+	}
+
+	response := &api.PingResponse{
+		TestID:   test.ID,
+		Status:   "success",
+		Latency:  float64(server.Latency.Milliseconds()),
+		ServerID: server.ID,
+		Sponsor:  server.Sponsor, Location: server.Name,
+		ServerHost: server.Host,
+		Country:    server.Country,
+		Distance:   server.Distance,
+		IPVersion:  opts.IPVersion,
+		Link:       opts.LinkName(),
+		Proxy:      opts.ProxyIdentity(),
+		Timestamp:  time.Now().UnixMilli(),
+
+		AssumedLocation: opts.Location,
+		Client:          t.clientInfoForResult(ctx, opts),
+	}
+
+	span.SetAttributes(attribute.Float64("speedtest.latency_ms", response.Latency))
+	slog.InfoContext(ctx, "ping complete",
+		"server_id", server.ID, "server", server.Name, "country", server.Country, "latency_ms", response.Latency)
+	t.notify(response)
+
+	return response, nil
+}
+
+// Download runs ping + download against the selected server
+func (t *Tester) Download(ctx context.Context, opts *TransferOptions) (*api.DownloadResponse, error) {
+	r, err := t.transfer(ctx, opts, PhaseDownload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.DownloadResponse{TransferResult: t.transferResponse(ctx, opts, r)}
+
+	slog.InfoContext(ctx, "download complete", "test_id", r.testID,
+		"server_id", r.server.ID, "server", r.server.Name, "speed_mbps", r.speedMbps, "duration_ms", r.duration.Milliseconds())
+	t.notify(response)
+
+	return response, nil
+}
+
+// Upload runs ping + upload against the selected server
+func (t *Tester) Upload(ctx context.Context, opts *TransferOptions) (*api.UploadResponse, error) {
+	r, err := t.transfer(ctx, opts, PhaseUpload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.UploadResponse{TransferResult: t.transferResponse(ctx, opts, r)}
+
+	slog.InfoContext(ctx, "upload complete", "test_id", r.testID,
+		"server_id", r.server.ID, "server", r.server.Name, "speed_mbps", r.speedMbps, "duration_ms", r.duration.Milliseconds())
+	t.notify(response)
+
+	return response, nil
+}
+
+// transferResponse fills the fields shared by download and upload results
+func (t *Tester) transferResponse(ctx context.Context, opts *TransferOptions, r *transferResult) api.TransferResult {
+	return api.TransferResult{
+		TestID:    r.testID,
+		SpeedMbps: r.speedMbps,
+		ServerID:  r.server.ID,
+		Sponsor:   r.server.Sponsor, Location: r.server.Name,
+		ServerHost: r.server.Host,
+		Country:    r.server.Country,
+		Latency:    r.latency,
+		DurationMs: r.duration.Milliseconds(),
+		IPVersion:  opts.IPVersion,
+		Link:       opts.LinkName(),
+		Proxy:      opts.ProxyIdentity(),
+		Timestamp:  time.Now().UnixMilli(),
+
+		AssumedLocation: opts.Location,
+		Client:          t.clientInfoForResult(ctx, &opts.Options),
+	}
+}
+
+// Full runs download then upload against one selected server
+func (t *Tester) Full(ctx context.Context, opts *FullOptions) (*FullResult, error) {
+	server, err := t.FindServer(ctx, &opts.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	phaseOpts := &TransferOptions{
+		Options:    opts.Options,
+		Duration:   opts.Duration,
+		OnStart:    opts.OnStart,
+		OnProgress: opts.OnProgress,
+	}
+	phaseOpts.Server = server
+
+	result := &FullResult{}
+	if !opts.SkipDownload {
+		if result.Download, err = t.Download(ctx, phaseOpts); err != nil {
+			return nil, err
+		}
+	}
+	if !opts.SkipUpload {
+		if result.Upload, err = t.Upload(ctx, phaseOpts); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// transferResult is the raw outcome of a download or upload
+type transferResult struct {
+	testID    string
+	server    *speedtest.Server
+	latency   float64 // ms
+	speedMbps float64
+	duration  time.Duration
+}
+
+// transfer pings the server, then runs the download or upload, reporting samples
+func (t *Tester) transfer(ctx context.Context, opts *TransferOptions, phase string) (*transferResult, error) {
+	ctx, span := startTest(ctx, phase, &opts.Options)
+	defer span.End()
+
+	test, testCtx, err := t.begin(ctx, phase)
+	if err != nil {
+		return nil, failed(ctx, phase, &opts.Options, err)
+	}
+	defer t.end(test)
+	ctx = testCtx
+	span.SetAttributes(attribute.String("speedtest.test.id", test.ID))
+	t.prefetchClientInfo(ctx, &opts.Options)
+
+	server, err := t.FindServer(ctx, &opts.Options)
+	if err != nil {
+		return nil, failed(ctx, phase, &opts.Options, err)
+	}
+	t.updateActive(test, activeServer(server))
+	span.SetAttributes(serverAttributes(server)...)
+
+	// Ping first untuk get latency
+	if err := pingServer(ctx, server); err != nil {
+		return nil, failed(ctx, phase, &opts.Options, err)
+	}
+	latency := float64(server.Latency.Milliseconds())
+	testID := test.ID
+	slog.DebugContext(ctx, phase+" started", "server_id", server.ID, "server", server.Name, "latency_ms", latency)
+	if opts.OnStart != nil {
+		opts.OnStart(server, latency)
+	}
+	t.notifyStart(Start{
+		TestID:    testID,
+		Phase:     phase,
+		Time:      time.Now(),
+		Server:    server,
+		LatencyMs: latency,
+		IPVersion: opts.IPVersion,
+		Link:      opts.LinkName(),
+		Proxy:     opts.ProxyIdentity(),
+
+		AssumedLocation: opts.Location,
+	})
+	// The transfer itself is a child span of the test
+	transferCtx, transferSpan := tracer.Start(ctx, phase, trace.WithAttributes(attribute.String("server.address", server.Host)))
+	defer transferSpan.End()
+	fail := func(err error) (*transferResult, error) {
+		err = failed(ctx, phase, &opts.Options, err)
+		recordError(transferSpan, err)
+		t.notifyFailure(Failure{TestID: testID, Phase: phase, Time: time.Now(), Err: err})
+		return nil, err
+	}
+
+	// Realtime speed samples from the speedtest-go callback
+	samples := make(chan float64, 100)
+	callback := func(rate speedtest.ByteRate) {
+		select {
+		case samples <- mbps(rate):
+		default:
+			// Channel full, skip this update
+		}
+	}
+
+	testCtx, cancel := context.WithCancel(transferCtx)
+	defer cancel()
+	done := make(chan error, 1)
+	startTime := time.Now()
+	totalBytes := server.Context.GetTotalDownload
+	if phase == PhaseUpload {
+		totalBytes = server.Context.GetTotalUpload
+		server.Context.SetCallbackUpload(callback)
+		go func() { done <- server.UploadTestContext(testCtx) }()
+	} else {
+		server.Context.SetCallbackDownload(callback)
+		go func() { done <- server.DownloadTestContext(testCtx) }()
+	}
+	// Reset server context untuk cleanup
+	defer server.Context.Reset()
+
+	var loadedLatency atomic.Int64 // ns
+	go probeLatency(testCtx, server, &loadedLatency)
+
+	var deadline <-chan time.Time
+	if opts.Duration > 0 {
+		timer := time.NewTimer(opts.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	result := func(speed float64) *transferResult {
+		r := &transferResult{testID: testID, server: server, latency: latency, speedMbps: speed, duration: time.Since(startTime)}
+		attrs := []attribute.KeyValue{
+			attribute.Float64("speedtest.speed_mbps", r.speedMbps),
+			attribute.Int64("speedtest.bytes", totalBytes()),
+			attribute.Int64("speedtest.duration_ms", r.duration.Milliseconds()),
+		}
+		transferSpan.SetAttributes(attrs...)
+		span.SetAttributes(append(attrs, attribute.Float64("speedtest.latency_ms", latency))...)
+		return r
+	}
+	var lastSpeed float64
+	for {
+		select {
+		case <-ctx.Done():
+			return fail(ctx.Err())
+		case err := <-done:
+			if err != nil {
+				return fail(&TestError{phase + "_failed", err})
+			}
+			finalRate := server.DLSpeed
+			if phase == PhaseUpload {
+				finalRate = server.ULSpeed
+			}
+			finalSpeed := mbps(finalRate)
+			if finalSpeed == 0 {
+				finalSpeed = lastSpeed
+			}
+			return result(finalSpeed), nil
+		case speed := <-samples:
+			lastSpeed = speed
+			t.updateActive(test, func(a *ActiveTest) { a.SpeedMbps = speed })
+			p := Progress{
+				Phase:         phase,
+				SpeedMbps:     speed,
+				Elapsed:       time.Since(startTime),
+				Bytes:         totalBytes(),
+				LoadedLatency: time.Duration(loadedLatency.Load()),
+			}
+			if opts.OnProgress != nil {
+				opts.OnProgress(p)
+			}
+			t.notifySample(Sample{
+				Progress:  p,
+				TestID:    testID,
+				Time:      time.Now(),
+				ServerID:  server.ID,
+				Sponsor:   server.Sponsor,
+				IPVersion: opts.IPVersion,
+				Link:      opts.LinkName(),
+				Proxy:     opts.ProxyIdentity(),
+			})
+		case <-deadline:
+			// Force stop after duration
+			return result(lastSpeed), nil
+		}
+	}
+}
+
+// activeServer records the selected server on an active test
+func activeServer(server *speedtest.Server) func(*ActiveTest) {
+	return func(a *ActiveTest) {
+		a.ServerID, a.Sponsor, a.Location = server.ID, server.Sponsor, server.Name
+	}
+}
+
+// loadedLatencyInterval is the time between latency probes during a transfer
+const loadedLatencyInterval = time.Second
+
+// probeLatency measures latency under load until ctx is done, storing the latest RTT (ns) in out
+func probeLatency(ctx context.Context, server *speedtest.Server, out *atomic.Int64) {
+	ticker := time.NewTicker(loadedLatencyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			latencies, err := server.HTTPPing(ctx, 1, 0, nil)
+			if err == nil && len(latencies) > 0 {
+				out.Store(latencies[len(latencies)-1])
+			}
+		}
+	}
+}
+
+// newTestID returns a random ID identifying one test (results, samples, history)
+func newTestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ==================== Packet Loss ====================
+
+// PacketLoss samples packet loss to opts.Server over the same link and IP
+// version as the transfers; ok=false when the server does not support it.
+// The sampling uses raw TCP/UDP to the server, so it cannot go through a proxy.
+func (t *Tester) PacketLoss(ctx context.Context, opts *Options, duration time.Duration) (loss float64, ok bool, err error) {
+	if opts.Server == nil {
+		return 0, false, errors.New("packet loss: no server selected")
+	}
+	if opts.Proxy != nil {
+		return 0, false, errors.New("packet loss cannot be measured through a proxy")
+	}
+	tcpDialer, udpDialer, err := packetLossDialers(opts)
+	if err != nil {
+		return 0, false, err
+	}
+	options := &speedtest.PacketLossAnalyzerOptions{
+		SamplingDuration: duration,
+		TCPDialer:        tcpDialer,
+		UDPDialer:        udpDialer,
+	}
+	if opts.Link != nil {
+		options.SourceInterface = opts.Link.SourceIP
+	}
+	analyzer := speedtest.NewPacketLossAnalyzer(options)
+
+	var last *transport.PLoss
+	err = analyzer.RunWithContext(ctx, opts.Server.Host, func(pl *transport.PLoss) {
+		last = pl
+	})
+	if err != nil || last == nil || last.Sent == 0 {
+		return 0, false, nil
+	}
+	return last.LossPercent(), true, nil
+}
+
+// packetLossDialers builds the sampler (TCP) and sender (UDP) dialers with
+// the source address, interface binding and address family newSpeedtestClient
+// gives the transfers
+func packetLossDialers(opts *Options) (tcpDialer, udpDialer *net.Dialer, err error) {
+	const timeout = 5 * time.Second // speedtest-go default PacketSendingTimeout
+	var familyControl, deviceControl func(network, address string, c syscall.RawConn) error
+	if opts.IPVersion == IPVersion4 || opts.IPVersion == IPVersion6 {
+		familyControl = ipFamilyControl(opts.IPVersion)
+	}
+	tcpDialer = &net.Dialer{Timeout: timeout}
+	udpDialer = &net.Dialer{Timeout: timeout}
+	if opts.Link != nil {
+		if opts.Link.SourceIP != "" {
+			ip := net.ParseIP(opts.Link.SourceIP)
+			if ip == nil {
+				return nil, nil, fmt.Errorf("link %s: invalid source IP %q", opts.Link.Name, opts.Link.SourceIP)
+			}
+			tcpDialer.LocalAddr = &net.TCPAddr{IP: ip}
+			udpDialer.LocalAddr = &net.UDPAddr{IP: ip}
+		}
+		if opts.Link.Interface != "" {
+			deviceControl = bindToDeviceControl(opts.Link.Interface)
+		}
+	}
+	tcpDialer.Control = chainControl(familyControl, deviceControl)
+	udpDialer.Control = tcpDialer.Control
+	return tcpDialer, udpDialer, nil
+}
@@ -0,0 +1,34 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProxyFailureHidesDialErrors(t *testing.T) {
+	proxy := &Options{Proxy: &Proxy{Name: "office", URL: &url.URL{Scheme: "socks5", Host: "10.0.0.1:1080"}}}
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connect: connection refused to 192.168.1.10:22")}
+
+	err := proxyFailure(proxy, fmt.Errorf("download: %w", dial))
+	if ErrorType(err) != "proxy_failed" || !errors.Is(err, ErrProxy) || strings.Contains(err.Error(), "192.168") {
+		t.Errorf("through a proxy: %v (%s), want proxy_failed without the dial target", err, ErrorType(err))
+	}
+
+	// Without a proxy the error is kept for the operator
+	if err := proxyFailure(&Options{}, dial); err != dial {
+		t.Errorf("without a proxy: %v, want the original error", err)
+	}
+
+	// Errors that say nothing about the network are kept
+	for _, keep := range []error{ErrServerNotFound, ErrInvalidServerID, context.Canceled, context.DeadlineExceeded,
+		&TestError{"cancelled", ErrCancelled}} {
+		if err := proxyFailure(proxy, keep); err != keep {
+			t.Errorf("%v through a proxy became %v", keep, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tester
+
+import (
+	"context"
+
+	"github.com/showwin/speedtest-go/speedtest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ==================== Tracing ====================
+//
+// Every test is a "test <phase>" span with children for the queue wait,
+// server selection (and its catalogue fetch), ping and the transfer.
+// Spans are no-ops until the application installs an OpenTelemetry
+// tracer provider with otel.SetTracerProvider.
+
+var tracer = otel.Tracer("go-speedtest/tester")
+
+// recordError marks span as failed with err
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// startTest starts the span of a whole test
+func startTest(ctx context.Context, phase string, opts *Options) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("speedtest.phase", phase)}
+	if requester := Requester(ctx); requester != "" {
+		attrs = append(attrs, attribute.String("speedtest.requester", requester))
+	}
+	if opts.ServerID != "" {
+		attrs = append(attrs, attribute.String("speedtest.server_id.requested", opts.ServerID))
+	}
+	if opts.IPVersion != "" {
+		attrs = append(attrs, attribute.String("speedtest.ip_version", opts.IPVersion))
+	}
+	if link := opts.LinkName(); link != "" {
+		attrs = append(attrs, attribute.String("speedtest.link", link))
+	}
+	if proxy := opts.ProxyIdentity(); proxy != "" {
+		attrs = append(attrs, attribute.String("speedtest.proxy", proxy))
+	}
+	return tracer.Start(ctx, "test "+phase, trace.WithAttributes(attrs...))
+}
+
+// serverAttributes describes the selected server
+func serverAttributes(server *speedtest.Server) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("speedtest.server.id", server.ID),
+		attribute.String("speedtest.server.sponsor", server.Sponsor),
+		attribute.String("speedtest.server.name", server.Name),
+		attribute.String("speedtest.server.country", server.Country),
+		attribute.String("server.address", server.Host),
+		attribute.Float64("speedtest.server.distance_km", server.Distance),
+	}
+}
+
+// pingServer measures latency to server in a "ping" span
+func pingServer(ctx context.Context, server *speedtest.Server) error {
+	ctx, span := tracer.Start(ctx, "ping", trace.WithAttributes(attribute.String("server.address", server.Host)))
+	defer span.End()
+
+	if err := server.PingTestContext(ctx, nil); err != nil {
+		recordError(span, err)
+		return &TestError{"ping_failed", err}
+	}
+	span.SetAttributes(
+		attribute.Float64("speedtest.latency_ms", float64(server.Latency.Microseconds())/1000),
+		attribute.Float64("speedtest.jitter_ms", float64(server.Jitter.Microseconds())/1000),
+	)
+	return nil
+}
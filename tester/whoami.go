@@ -0,0 +1,147 @@
+package tester
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go-speedtest/api"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ==================== Client Info ====================
+
+const (
+	// clientInfoTTL is how long the user-info lookup is cached
+	clientInfoTTL = 10 * time.Minute
+	// clientInfoTimeout bounds one user-info lookup
+	clientInfoTimeout = 5 * time.Second
+	// clientInfoWait is how long a finished test waits for a lookup still in
+	// flight; client info is only metadata, so a slow whoami must not hold up results
+	clientInfoWait = 500 * time.Millisecond
+)
+
+// clientInfoEntry is a cached user-info lookup
+type clientInfoEntry struct {
+	info      *api.ClientInfo
+	fetchedAt time.Time
+}
+
+// clientInfoKey identifies the egress path; the public IP differs per link, proxy and address family
+func clientInfoKey(opts *Options) string {
+	version := opts.IPVersion
+	if version == IPVersionBoth {
+		version = ""
+	}
+	return opts.LinkName() + "/" + opts.ProxyIdentity() + "/" + version
+}
+
+// ClientInfo returns the public IP/ISP of the egress path, cached per path.
+// refresh bypasses the cache. Concurrent lookups of the same path share one
+// fetch, which runs with its own clientInfoTimeout and is not cancelled when
+// a caller gives up.
+func (t *Tester) ClientInfo(ctx context.Context, opts *Options, refresh bool) (*api.ClientInfo, error) {
+	key := clientInfoKey(opts)
+	if !refresh {
+		if info, ok := t.cachedClientInfo(key); ok {
+			return info, nil
+		}
+	}
+
+	ch := t.lookupClientInfo(ctx, opts, key)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*api.ClientInfo), nil
+	}
+}
+
+// lookupClientInfo starts the lookup for key, or joins the one in flight
+func (t *Tester) lookupClientInfo(ctx context.Context, opts *Options, key string) <-chan singleflight.Result {
+	return t.clientInfoGroup.DoChan(key, func() (interface{}, error) {
+		return t.fetchClientInfo(context.WithoutCancel(ctx), opts, key)
+	})
+}
+
+// cachedClientInfo returns the cached lookup for key unless it expired
+func (t *Tester) cachedClientInfo(key string) (*api.ClientInfo, bool) {
+	t.clientInfoMu.Lock()
+	defer t.clientInfoMu.Unlock()
+	entry, ok := t.clientInfo[key]
+	if !ok || time.Since(entry.fetchedAt) >= clientInfoTTL {
+		return nil, false
+	}
+	return entry.info, true
+}
+
+// fetchClientInfo looks up the public IP/ISP and caches it under key
+func (t *Tester) fetchClientInfo(ctx context.Context, opts *Options, key string) (*api.ClientInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, clientInfoTimeout)
+	defer cancel()
+
+	info, err := t.fetchUserInfo(ctx, opts)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch user info: %w", err)
+		if opts.Proxy != nil {
+			slog.WarnContext(ctx, "whoami through proxy failed", "proxy", opts.ProxyIdentity(), "error", err)
+		}
+		return nil, proxyFailure(opts, err)
+	}
+
+	t.clientInfoMu.Lock()
+	t.clientInfo[key] = clientInfoEntry{info: info, fetchedAt: time.Now()}
+	t.clientInfoMu.Unlock()
+	return info, nil
+}
+
+// fetchSpeedtestUserInfo asks speedtest.net for the public IP/ISP of the egress path
+func fetchSpeedtestUserInfo(ctx context.Context, opts *Options) (*api.ClientInfo, error) {
+	user, err := newSpeedtestClient(opts).FetchUserInfoContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lat, _ := strconv.ParseFloat(user.Lat, 64)
+	lon, _ := strconv.ParseFloat(user.Lon, 64)
+	return &api.ClientInfo{
+		IP:  user.IP,
+		ISP: user.Isp,
+		Lat: lat,
+		Lon: lon,
+	}, nil
+}
+
+// prefetchClientInfo starts the lookup in the background when a test begins,
+// so it runs alongside the test instead of after it
+func (t *Tester) prefetchClientInfo(ctx context.Context, opts *Options) {
+	if t.SkipClientInfo {
+		return
+	}
+	key := clientInfoKey(opts)
+	if _, ok := t.cachedClientInfo(key); !ok {
+		t.lookupClientInfo(ctx, opts, key)
+	}
+}
+
+// clientInfoForResult returns client info for attaching to results: the
+// cached value, or the lookup in flight if it finishes within clientInfoWait.
+// Lookup failure is logged, tidak menggagalkan test.
+func (t *Tester) clientInfoForResult(ctx context.Context, opts *Options) *api.ClientInfo {
+	if t.SkipClientInfo {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, clientInfoWait)
+	defer cancel()
+	info, err := t.ClientInfo(ctx, opts, false)
+	if err != nil {
+		slog.WarnContext(ctx, "whoami lookup failed", "error", err)
+		return nil
+	}
+	return info
+}
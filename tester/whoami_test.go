@@ -0,0 +1,138 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-speedtest/api"
+)
+
+// stubUserInfo replaces the speedtest.net lookup of tr; every fetch waits for
+// release (if set) and is counted in calls
+func stubUserInfo(tr *Tester, release chan struct{}, err error) *atomic.Int32 {
+	var calls atomic.Int32
+	tr.fetchUserInfo = func(ctx context.Context, opts *Options) (*api.ClientInfo, error) {
+		n := calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &api.ClientInfo{IP: "192.0.2.1", ISP: "Test ISP", Lat: float64(n)}, nil
+	}
+	return &calls
+}
+
+func TestClientInfoSharesOneLookup(t *testing.T) {
+	tr := New()
+	release := make(chan struct{})
+	calls := stubUserInfo(tr, release, nil)
+
+	var wg sync.WaitGroup
+	infos := make([]*api.ClientInfo, 5)
+	for i := range infos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			infos[i], _ = tr.ClientInfo(context.Background(), &Options{}, false)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d lookups for concurrent callers, want 1", n)
+	}
+	for i, info := range infos {
+		if info == nil || info != infos[0] {
+			t.Errorf("caller %d got %+v, want the shared result", i, info)
+		}
+	}
+
+	// Cached until the TTL, per egress path, and refresh bypasses the cache
+	if info, _ := tr.ClientInfo(context.Background(), &Options{}, false); info != infos[0] || calls.Load() != 1 {
+		t.Errorf("second call: %+v after %d lookups, want the cached result", info, calls.Load())
+	}
+	if _, err := tr.ClientInfo(context.Background(), &Options{IPVersion: IPVersion4}, false); err != nil || calls.Load() != 2 {
+		t.Errorf("IPv4 path: %v after %d lookups, want its own lookup", err, calls.Load())
+	}
+	if info, _ := tr.ClientInfo(context.Background(), &Options{}, true); info == infos[0] || calls.Load() != 3 {
+		t.Errorf("refresh: %+v after %d lookups, want a new lookup", info, calls.Load())
+	}
+}
+
+func TestClientInfoExpires(t *testing.T) {
+	tr := New()
+	calls := stubUserInfo(tr, nil, nil)
+	if _, err := tr.ClientInfo(context.Background(), &Options{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	key := clientInfoKey(&Options{})
+	tr.clientInfoMu.Lock()
+	entry := tr.clientInfo[key]
+	entry.fetchedAt = time.Now().Add(-clientInfoTTL)
+	tr.clientInfo[key] = entry
+	tr.clientInfoMu.Unlock()
+
+	if _, err := tr.ClientInfo(context.Background(), &Options{}, false); err != nil || calls.Load() != 2 {
+		t.Errorf("after the TTL: %v after %d lookups, want a new lookup", err, calls.Load())
+	}
+}
+
+func TestClientInfoErrorsAreNotCached(t *testing.T) {
+	tr := New()
+	calls := stubUserInfo(tr, nil, errors.New("whoami unreachable"))
+	for i := 0; i < 2; i++ {
+		if info := tr.clientInfoForResult(context.Background(), &Options{}); info != nil {
+			t.Errorf("failed lookup attached %+v", info)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d lookups, want a retry after a failure", n)
+	}
+}
+
+func TestClientInfoForResultDoesNotWait(t *testing.T) {
+	tr := New()
+	release := make(chan struct{})
+	calls := stubUserInfo(tr, release, nil)
+
+	// The lookup starts with the test and a slow one is given up on
+	tr.prefetchClientInfo(context.Background(), &Options{})
+	start := time.Now()
+	if info := tr.clientInfoForResult(context.Background(), &Options{}); info != nil {
+		t.Errorf("slow lookup attached %+v", info)
+	}
+	if elapsed := time.Since(start); elapsed > clientInfoWait+time.Second {
+		t.Errorf("result waited %v for whoami", elapsed)
+	}
+
+	// It keeps running and the next result gets its value
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := tr.cachedClientInfo(clientInfoKey(&Options{})); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("abandoned lookup was not cached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if info := tr.clientInfoForResult(context.Background(), &Options{}); info == nil || calls.Load() != 1 {
+		t.Errorf("next result: %+v after %d lookups, want the cached value", info, calls.Load())
+	}
+
+	tr.SkipClientInfo = true
+	tr.prefetchClientInfo(context.Background(), &Options{IPVersion: IPVersion4})
+	if info := tr.clientInfoForResult(context.Background(), &Options{IPVersion: IPVersion4}); info != nil || calls.Load() != 1 {
+		t.Errorf("SkipClientInfo: %+v after %d lookups, want none", info, calls.Load())
+	}
+}
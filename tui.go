@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"go-speedtest/api"
+
+	"golang.org/x/term"
+)
+
+// ==================== Terminal Dashboard ====================
+
+const (
+	tuiGaugeWidth     = 40
+	tuiSparkWidth     = 50
+	tuiRefresh        = 100 * time.Millisecond
+	tuiServerListSize = 20
+)
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// tuiPhase is the live state of one stream (download or upload)
+type tuiPhase struct {
+	name    string
+	samples []float64
+	speed   float64
+	peak    float64
+	elapsed float64
+	done    bool
+	err     string
+}
+
+// tuiEvent is a stream event delivered to the UI loop
+type tuiEvent struct {
+	phase int
+	event StreamEvent
+	err   error
+	end   bool // stream closed
+}
+
+// tuiModel holds everything the screen renders
+type tuiModel struct {
+	client   *api.Client
+	duration int
+	serverID string
+
+	start   *StreamEvent // from the first start event
+	phases  []*tuiPhase
+	running bool
+	status  string
+}
+
+// cmdTUI - speedtest tui --url http://host:8645
+// Live dashboard for a running instance's SSE streams
+func cmdTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	baseURL := fs.String("url", "http://127.0.0.1:"+DefaultPort, "Running instance URL")
+	test := fs.String("test", "both", "Streams to run: download, upload or both")
+	duration := fs.Int("duration", 10, "Test duration per stream in seconds (max 30)")
+	serverID := fs.String("server", "", "Server ID (default: pick from list)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	var phases []string
+	switch *test {
+	case "download", "upload":
+		phases = []string{*test}
+	case "both":
+		phases = []string{"download", "upload"}
+	default:
+		return usageError(fmt.Errorf("invalid test %q (use download, upload or both)", *test))
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return usageError(errors.New("tui requires an interactive terminal"))
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	// Alternate screen + hidden cursor, restored on exit
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, oldState)
+	}()
+
+	keys := make(chan string, 16)
+	go readKeys(os.Stdin, keys)
+
+	m := &tuiModel{
+		client:   api.NewClient(*baseURL),
+		duration: *duration,
+		serverID: *serverID,
+	}
+	if m.serverID == "" {
+		id, ok := pickServer(m.client, keys)
+		if !ok {
+			return exitOK
+		}
+		m.serverID = id
+	}
+
+	for {
+		action := m.run(phases, keys)
+		switch action {
+		case "q":
+			return exitOK
+		case "s":
+			if id, ok := pickServer(m.client, keys); ok {
+				m.serverID = id
+			} else {
+				return exitOK
+			}
+		}
+	}
+}
+
+// run streams each phase, rendering live, and returns the key that ended it ("q", "r" or "s")
+func (m *tuiModel) run(phaseNames []string, keys <-chan string) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m.start = nil
+	m.phases = nil
+	for _, name := range phaseNames {
+		m.phases = append(m.phases, &tuiPhase{name: name})
+	}
+	m.running = true
+	m.status = "Connecting..."
+
+	events := make(chan tuiEvent, 64)
+	send := func(ev tuiEvent) {
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(events)
+		for i, phase := range m.phases {
+			err := m.stream(ctx, phase.name, func(ev StreamEvent) {
+				send(tuiEvent{phase: i, event: ev})
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			send(tuiEvent{phase: i, err: err, end: true})
+		}
+	}()
+
+	ticker := time.NewTicker(tuiRefresh)
+	defer ticker.Stop()
+	m.render()
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return "q"
+			}
+			switch key {
+			case "q", "ctrl-c":
+				return "q"
+			case "r", "s":
+				if !m.running || key == "s" {
+					return key
+				}
+			}
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				m.running = false
+				m.status = "Finished - r: rerun, s: pick server, q: quit"
+				m.render()
+				continue
+			}
+			m.apply(ev)
+		case <-ticker.C:
+			m.render()
+		}
+	}
+}
+
+// stream connects to one SSE endpoint and calls fn for every event
+func (m *tuiModel) stream(ctx context.Context, phase string, fn func(StreamEvent)) error {
+	open := m.client.DownloadStream
+	if phase == "upload" {
+		open = m.client.UploadStream
+	}
+	stream, err := open(ctx, &api.Options{
+		ServerID: m.serverID,
+		Duration: time.Duration(m.duration) * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	for ev := range stream.Events {
+		fn(ev)
+	}
+	return stream.Err()
+}
+
+// apply updates the model from a stream event
+func (m *tuiModel) apply(ev tuiEvent) {
+	phase := m.phases[ev.phase]
+	if ev.end {
+		if ev.err != nil && !phase.done && phase.err == "" {
+			phase.err = ev.err.Error()
+		}
+		return
+	}
+
+	switch ev.event.Type {
+	case "start":
+		start := ev.event
+		m.start = &start
+		m.status = "Testing " + phase.name + "..."
+	case "progress":
+		phase.speed = ev.event.SpeedMbps
+		phase.elapsed = ev.event.Elapsed
+		phase.samples = append(phase.samples, ev.event.SpeedMbps)
+		phase.peak = math.Max(phase.peak, ev.event.SpeedMbps)
+	case "complete":
+		phase.speed = ev.event.SpeedMbps
+		phase.elapsed = ev.event.Elapsed
+		phase.peak = math.Max(phase.peak, ev.event.SpeedMbps)
+		phase.done = true
+	case "error":
+		phase.err = ev.event.Message
+	}
+}
+
+// render redraws the whole dashboard
+func (m *tuiModel) render() {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	line := func(format string, a ...interface{}) {
+		fmt.Fprintf(&b, format+"\r\n", a...)
+	}
+
+	line("\x1b[1mGO-Speedtest\x1b[0m  %s", m.client.BaseURL)
+	line("")
+	if m.start != nil {
+		line("Server:   %s (%s) [%s]", m.start.Sponsor, m.start.Location, m.start.ServerID)
+		line("Latency:  %.0f ms", m.start.Latency)
+	} else {
+		line("Server:   %s", valueOr(m.serverID, "closest"))
+		line("Latency:  -")
+	}
+	line("")
+
+	scale := gaugeScale(m.phases)
+	for _, p := range m.phases {
+		title := strings.ToUpper(p.name[:1]) + p.name[1:]
+		switch {
+		case p.err != "":
+			line("%-9s \x1b[31merror: %s\x1b[0m", title, p.err)
+		default:
+			mark := ""
+			if p.done {
+				mark = " \x1b[32m✓\x1b[0m"
+			}
+			line("%-9s %s %8.2f Mbps%s", title, gauge(p.speed, scale, tuiGaugeWidth), p.speed, mark)
+			line("          %s  peak %.2f  %.1fs", sparkline(p.samples, tuiSparkWidth), p.peak, p.elapsed)
+		}
+		line("")
+	}
+
+	line("\x1b[2m%s\x1b[0m", m.status)
+	os.Stdout.WriteString(b.String())
+}
+
+// ==================== Server Picker ====================
+
+// pickServer lists /speedtest/servers and lets the user choose; ok=false on quit.
+// Returns "" for the closest server.
+func pickServer(client *api.Client, keys <-chan string) (string, bool) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2JFetching servers...\r\n")
+	os.Stdout.WriteString(b.String())
+
+	resp, err := client.Servers(context.Background(), nil)
+	if err != nil {
+		fmt.Printf("\x1b[31mError: %v\x1b[0m\r\n\r\nEnter: use closest server, q: quit\r\n", err)
+		for key := range keys {
+			switch key {
+			case "enter":
+				return "", true
+			case "q", "ctrl-c":
+				return "", false
+			}
+		}
+		return "", false
+	}
+	servers := resp.Servers
+	if len(servers) > tuiServerListSize {
+		servers = servers[:tuiServerListSize]
+	}
+
+	// Index 0 = closest (auto)
+	selected := 0
+	for {
+		b.Reset()
+		b.WriteString("\x1b[H\x1b[2J\x1b[1mSelect server\x1b[0m  (↑/↓ or j/k, Enter: select, q: quit)\r\n\r\n")
+		row := func(i int, text string) {
+			if i == selected {
+				fmt.Fprintf(&b, "\x1b[7m> %s\x1b[0m\r\n", text)
+			} else {
+				fmt.Fprintf(&b, "  %s\r\n", text)
+			}
+		}
+		row(0, "Closest server (auto)")
+		for i, s := range servers {
+			row(i+1, fmt.Sprintf("%-7s %-30.30s %-20.20s %-12.12s %7.1f km",
+				s.ID, s.Sponsor, s.Location, s.Country, s.Distance))
+		}
+		os.Stdout.WriteString(b.String())
+
+		key, ok := <-keys
+		if !ok {
+			return "", false
+		}
+		switch key {
+		case "up", "k":
+			if selected > 0 {
+				selected--
+			}
+		case "down", "j":
+			if selected < len(servers) {
+				selected++
+			}
+		case "enter":
+			if selected == 0 {
+				return "", true
+			}
+			return servers[selected-1].ID, true
+		case "q", "ctrl-c":
+			return "", false
+		}
+	}
+}
+
+// ==================== TUI Helpers ====================
+
+// readKeys reads raw terminal input and emits key names
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 8)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		in := buf[:n]
+		switch {
+		case n >= 3 && in[0] == 0x1b && in[1] == '[' && in[2] == 'A':
+			keys <- "up"
+		case n >= 3 && in[0] == 0x1b && in[1] == '[' && in[2] == 'B':
+			keys <- "down"
+		case in[0] == '\r' || in[0] == '\n':
+			keys <- "enter"
+		case in[0] == 3:
+			keys <- "ctrl-c"
+		default:
+			keys <- strings.ToLower(string(in[0]))
+		}
+	}
+}
+
+// gaugeScale picks a round full-scale value covering the highest peak
+func gaugeScale(phases []*tuiPhase) float64 {
+	peak := 0.0
+	for _, p := range phases {
+		peak = math.Max(peak, p.peak)
+	}
+	for _, scale := range []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000} {
+		if peak <= scale {
+			return scale
+		}
+	}
+	return math.Ceil(peak/10000) * 10000
+}
+
+// gauge renders a horizontal bar for value out of max
+func gauge(value, max float64, width int) string {
+	filled := int(math.Round(value / max * float64(width)))
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return "\x1b[36m" + strings.Repeat("█", filled) + "\x1b[0m" + strings.Repeat("░", width-filled)
+}
+
+// sparkline renders the last width samples scaled to their own maximum
+func sparkline(samples []float64, width int) string {
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	max := 0.0
+	for _, s := range samples {
+		max = math.Max(max, s)
+	}
+	var b strings.Builder
+	for _, s := range samples {
+		i := 0
+		if max > 0 {
+			i = int(s / max * float64(len(sparkChars)-1))
+		}
+		b.WriteRune(sparkChars[i])
+	}
+	return b.String()
+}
+
+// valueOr returns v, or fallback when v is empty
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
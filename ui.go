@@ -0,0 +1,32 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// ==================== Web Dashboard ====================
+
+// uiFiles is the dashboard (HTML/CSS/JS), embedded so the binary works offline
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiHandler - GET /ui/
+// Serves the embedded single-page dashboard; it calls the API on the same origin
+func uiHandler() http.Handler {
+	sub, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // embedded path is fixed at build time
+	}
+	files := http.StripPrefix("/ui/", http.FileServer(http.FS(sub)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,378 @@
+// GO-Speedtest dashboard - talks to the same-origin API, no external assets.
+(function () {
+  "use strict";
+
+  var HISTORY_KEY = "go-speedtest.history";
+  var HISTORY_LIMIT = 50;
+
+  var $ = function (id) { return document.getElementById(id); };
+
+  var state = {
+    source: null,    // active EventSource
+    stopped: false,
+    samples: [],     // {t, v} for the live chart
+    phase: "",
+    result: null     // result of the running test
+  };
+
+  // ==================== Gauge ====================
+
+  var arc = $("gaugeArc");
+  var arcLength = arc.getTotalLength();
+  arc.style.strokeDasharray = arcLength;
+  arc.style.strokeDashoffset = arcLength;
+
+  // gaugeScale picks the next "nice" full-scale value above speed
+  function gaugeScale(speed) {
+    var steps = [10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000];
+    for (var i = 0; i < steps.length; i++) {
+      if (speed <= steps[i]) return steps[i];
+    }
+    return steps[steps.length - 1];
+  }
+
+  function setGauge(speed, upload) {
+    var max = gaugeScale(speed);
+    var ratio = Math.min(speed / max, 1);
+    arc.style.strokeDashoffset = arcLength * (1 - ratio);
+    arc.style.stroke = upload ? "var(--upload)" : "var(--accent)";
+    $("gaugeValue").textContent = speed.toFixed(2);
+    $("gaugeMax").textContent = max;
+  }
+
+  // ==================== Charts ====================
+
+  // drawLines plots series ({values, color}) on a canvas, x evenly spaced
+  function drawLines(canvas, series, labels) {
+    var ctx = canvas.getContext("2d");
+    var w = canvas.width, h = canvas.height, pad = 28;
+    ctx.clearRect(0, 0, w, h);
+
+    var max = 0, count = 0;
+    series.forEach(function (s) {
+      count = Math.max(count, s.values.length);
+      s.values.forEach(function (v) { if (v > max) max = v; });
+    });
+    if (count === 0) return;
+    max = gaugeScale(max);
+
+    ctx.strokeStyle = "#26303a";
+    ctx.fillStyle = "#8b98a5";
+    ctx.font = "11px system-ui, sans-serif";
+    ctx.lineWidth = 1;
+    for (var i = 0; i <= 4; i++) {
+      var y = pad / 2 + (h - pad) * i / 4;
+      ctx.beginPath();
+      ctx.moveTo(pad, y);
+      ctx.lineTo(w, y);
+      ctx.stroke();
+      ctx.fillText(String(Math.round(max * (4 - i) / 4)), 0, y + 4);
+    }
+
+    var xAt = function (i) {
+      return count === 1 ? (pad + w) / 2 : pad + (w - pad - 4) * i / (count - 1);
+    };
+    series.forEach(function (s) {
+      ctx.strokeStyle = s.color;
+      ctx.fillStyle = s.color;
+      ctx.lineWidth = 2;
+      ctx.beginPath();
+      s.values.forEach(function (v, i) {
+        var x = xAt(i), y = pad / 2 + (h - pad) * (1 - v / max);
+        if (i === 0) ctx.moveTo(x, y); else ctx.lineTo(x, y);
+      });
+      ctx.stroke();
+      if (s.points) {
+        s.values.forEach(function (v, i) {
+          ctx.beginPath();
+          ctx.arc(xAt(i), pad / 2 + (h - pad) * (1 - v / max), 3, 0, 2 * Math.PI);
+          ctx.fill();
+        });
+      }
+    });
+
+    if (labels && labels.length) {
+      ctx.fillStyle = "#8b98a5";
+      ctx.fillText(labels[0], pad, h - 2);
+      var last = labels[labels.length - 1];
+      ctx.fillText(last, w - ctx.measureText(last).width, h - 2);
+    }
+  }
+
+  function drawLive(upload) {
+    drawLines($("live"), [{
+      values: state.samples.map(function (s) { return s.v; }),
+      color: upload ? "#be4bdb" : "#22b8cf"
+    }]);
+  }
+
+  // ==================== History ====================
+  //
+  // History comes from the server (/speedtest/history), so it includes
+  // tests from every client and survives browser changes. localStorage is
+  // only used while the server history cannot be loaded.
+
+  var historySource = "server"; // "server" or "local"
+  var historyRows = [];         // {time, server, latency, download, upload}, oldest first
+
+  function loadLocalHistory() {
+    try {
+      return JSON.parse(localStorage.getItem(HISTORY_KEY)) || [];
+    } catch (e) {
+      return [];
+    }
+  }
+
+  // serverHistoryRows turns history entries (newest first, one per test)
+  // into rows; an upload right after a download to the same server is the
+  // same run
+  function serverHistoryRows(entries) {
+    var rows = [];
+    entries.slice().reverse().forEach(function (e) {
+      var r = e.result || {};
+      var prev = rows[rows.length - 1];
+      if (e.type === "upload" && prev && prev.download != null && prev.upload == null &&
+          prev.serverID === r.server_id && e.timestamp - prev.timestamp < 120000) {
+        prev.upload = r.speed_mbps;
+        return;
+      }
+      rows.push({
+        time: new Date(e.timestamp).toISOString(),
+        timestamp: e.timestamp,
+        serverID: r.server_id,
+        server: r.sponsor ? r.sponsor + " - " + r.location : "",
+        latency: r.latency_ms,
+        download: e.type === "download" ? r.speed_mbps : null,
+        upload: e.type === "upload" ? r.speed_mbps : null
+      });
+    });
+    return rows.slice(-HISTORY_LIMIT);
+  }
+
+  // refreshHistory loads the server history, falling back to localStorage
+  function refreshHistory() {
+    return fetch("../speedtest/history?limit=" + HISTORY_LIMIT * 2)
+      .then(function (resp) {
+        if (!resp.ok) throw new Error("HTTP " + resp.status);
+        return resp.json();
+      })
+      .then(function (data) {
+        historySource = "server";
+        historyRows = serverHistoryRows(data.entries || []);
+      })
+      .catch(function () {
+        historySource = "local";
+        historyRows = loadLocalHistory();
+      })
+      .then(renderHistory);
+  }
+
+  // saveHistory records a finished run: the server already has it, so only
+  // the local fallback is written, and only while the server is unreachable
+  function saveHistory(entry) {
+    refreshHistory().then(function () {
+      if (historySource !== "local") return;
+      var history = loadLocalHistory();
+      history.push(entry);
+      if (history.length > HISTORY_LIMIT) history = history.slice(-HISTORY_LIMIT);
+      try {
+        localStorage.setItem(HISTORY_KEY, JSON.stringify(history));
+      } catch (e) { /* storage full or disabled */ }
+      historyRows = history;
+      renderHistory();
+    });
+  }
+
+  function fmt(v, digits) {
+    return v == null ? "-" : v.toFixed(digits);
+  }
+
+  function renderHistory() {
+    var history = historyRows;
+    // Only the browser-local fallback can be cleared
+    $("clearHistory").hidden = historySource !== "local";
+    $("historySource").textContent = historySource === "local" ? "Server history unavailable; showing tests stored in this browser." : "";
+
+    var rows = $("historyRows");
+    rows.textContent = "";
+    history.slice().reverse().forEach(function (h) {
+      var tr = document.createElement("tr");
+      [
+        new Date(h.time).toLocaleString(),
+        h.server || "-",
+        fmt(h.latency, 2) + " ms",
+        fmt(h.download, 2) + " Mbps",
+        fmt(h.upload, 2) + " Mbps"
+      ].forEach(function (text) {
+        var td = document.createElement("td");
+        td.textContent = text;
+        tr.appendChild(td);
+      });
+      rows.appendChild(tr);
+    });
+
+    // Ping-only rows have no speed to chart
+    var speeds = history.filter(function (h) { return h.download != null || h.upload != null; });
+    var pick = function (key) {
+      return speeds.map(function (h) { return h[key] || 0; });
+    };
+    var labels = speeds.map(function (h) { return new Date(h.time).toLocaleDateString(); });
+    drawLines($("history"), [
+      { values: pick("download"), color: "#22b8cf", points: true },
+      { values: pick("upload"), color: "#be4bdb", points: true }
+    ], labels);
+    if (speeds.length === 0) {
+      var canvas = $("history");
+      canvas.getContext("2d").clearRect(0, 0, canvas.width, canvas.height);
+    }
+  }
+
+  // ==================== Servers ====================
+
+  function loadServers() {
+    fetch("../speedtest/servers")
+      .then(function (resp) { return resp.json(); })
+      .then(function (data) {
+        if (!data.servers) {
+          setStatus("Server list unavailable: " + (data.message || "unknown error"), true);
+          return;
+        }
+        var select = $("server");
+        data.servers.forEach(function (s) {
+          var opt = document.createElement("option");
+          opt.value = s.id;
+          opt.textContent = s.sponsor + " - " + s.location + " (" + s.distance_km.toFixed(0) + " km)";
+          select.appendChild(opt);
+        });
+      })
+      .catch(function (err) {
+        setStatus("Server list unavailable: " + err.message, true);
+      });
+  }
+
+  // ==================== Test Flow ====================
+
+  function setStatus(text, isError) {
+    var el = $("status");
+    el.textContent = text;
+    el.className = isError ? "error" : "muted";
+  }
+
+  function setRunning(running) {
+    $("start").disabled = running;
+    $("stop").disabled = !running;
+    $("server").disabled = running;
+  }
+
+  // streamPhase runs one SSE test ("download" or "upload"), resolving with the complete event
+  function streamPhase(kind) {
+    return new Promise(function (resolve, reject) {
+      var params = new URLSearchParams();
+      var serverID = $("server").value;
+      if (serverID) params.set("server_id", serverID);
+      params.set("duration", $("duration").value || "10");
+
+      var upload = kind === "upload";
+      state.samples = [];
+      drawLive(upload);
+      setGauge(0, upload);
+      $("phase").textContent = upload ? "Upload" : "Download";
+
+      var done = false;
+      var source = new EventSource("../speedtest/" + kind + "/stream?" + params.toString());
+      state.source = source;
+
+      source.onmessage = function (msg) {
+        var ev;
+        try { ev = JSON.parse(msg.data); } catch (e) { return; }
+
+        switch (ev.type) {
+          case "start":
+            $("serverInfo").textContent = ev.sponsor + " - " + ev.location + " [" + ev.server_id + "]";
+            if (ev.latency_ms) $("rLatency").textContent = ev.latency_ms.toFixed(2);
+            state.result.server = ev.sponsor + " - " + ev.location;
+            state.result.latency = ev.latency_ms;
+            break;
+          case "progress":
+            setGauge(ev.speed_mbps, upload);
+            state.samples.push({ t: ev.elapsed_sec, v: ev.speed_mbps });
+            drawLive(upload);
+            break;
+          case "complete":
+            done = true;
+            source.close();
+            setGauge(ev.speed_mbps, upload);
+            resolve(ev);
+            break;
+          case "error":
+            done = true;
+            source.close();
+            reject(new Error(ev.message || "test failed"));
+            break;
+        }
+      };
+      source.onerror = function () {
+        // EventSource reconnects by itself; a dropped test is not resumable
+        source.close();
+        if (!done) reject(new Error(state.stopped ? "stopped" : "connection lost"));
+      };
+    });
+  }
+
+  function startTest() {
+    var phases = [];
+    if ($("doDownload").checked) phases.push("download");
+    if ($("doUpload").checked) phases.push("upload");
+    if (phases.length === 0) {
+      setStatus("Select download and/or upload", true);
+      return;
+    }
+
+    state.stopped = false;
+    state.result = { time: new Date().toISOString() };
+    ["rLatency", "rDownload", "rUpload"].forEach(function (id) { $(id).textContent = "-"; });
+    setRunning(true);
+    setStatus("Running...");
+
+    var chain = Promise.resolve();
+    phases.forEach(function (kind) {
+      chain = chain.then(function () {
+        return streamPhase(kind).then(function (ev) {
+          state.result[kind] = ev.speed_mbps;
+          $(kind === "download" ? "rDownload" : "rUpload").textContent = ev.speed_mbps.toFixed(2);
+        });
+      });
+    });
+
+    chain.then(function () {
+      saveHistory(state.result);
+      setStatus("Done");
+      $("phase").textContent = "Complete";
+    }).catch(function (err) {
+      setStatus("Test failed: " + err.message, !state.stopped);
+      $("phase").textContent = state.stopped ? "Stopped" : "Failed";
+    }).then(function () {
+      state.source = null;
+      setRunning(false);
+    });
+  }
+
+  function stopTest() {
+    state.stopped = true;
+    if (state.source) {
+      state.source.close();
+      state.source.onerror();
+    }
+  }
+
+  $("start").addEventListener("click", startTest);
+  $("stop").addEventListener("click", stopTest);
+  $("clearHistory").addEventListener("click", function () {
+    localStorage.removeItem(HISTORY_KEY);
+    historyRows = [];
+    renderHistory();
+  });
+
+  loadServers();
+  refreshHistory();
+})();
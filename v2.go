@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+)
+
+// ==================== API v2 ====================
+//
+// /api/v2 wraps every success in api.Envelope and every failure in an
+// RFC 7807 api.Problem with a stable code and a meaningful status.
+// The /speedtest/* routes stay as they are (v1 compatibility layer);
+// both versions run the same tester calls.
+
+const (
+	// v2DefaultTimeout bounds a v2 request unless ?timeout= is given
+	v2DefaultTimeout = 2 * time.Minute
+	// v2MaxTimeout is the largest accepted ?timeout= in seconds
+	v2MaxTimeout = 300
+)
+
+// v2Run produces the data of a v2 response
+type v2Run func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error)
+
+// v2Handler adapts run to the v2 envelope: method check, options, timeout and problem mapping.
+// endpoint names the route in log lines (e.g. "ping").
+func v2Handler(endpoint string, run v2Run) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if r.Method != http.MethodGet {
+			writeProblem(w, r, http.StatusMethodNotAllowed, api.CodeMethodNotAllowed, "Only GET method is allowed")
+			return
+		}
+
+		opts, err := parseTestOptions(r)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+			return
+		}
+		timeout, err := parseV2Timeout(r)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		data, err := run(ctx, r, opts)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timed out after %s: %w", timeout, context.DeadlineExceeded)
+			}
+			slog.WarnContext(r.Context(), "v2 request failed", "endpoint", endpoint, "error", err)
+			writeProblemErr(w, r, err)
+			return
+		}
+		writeEnvelope(w, http.StatusOK, data, start)
+	}
+}
+
+// parseV2Timeout reads ?timeout= in seconds (default v2DefaultTimeout)
+func parseV2Timeout(r *http.Request) (time.Duration, error) {
+	raw := r.URL.Query().Get("timeout")
+	if raw == "" {
+		return v2DefaultTimeout, nil
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 1 || seconds > v2MaxTimeout {
+		return 0, fmt.Errorf("invalid timeout %q (use 1-%d seconds)", raw, v2MaxTimeout)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// writeEnvelope writes a v2 success response
+func writeEnvelope(w http.ResponseWriter, status int, data interface{}, start time.Time) {
+	writeJSON(w, status, api.Envelope{
+		Data: data,
+		Meta: api.Meta{
+			APIVersion: "2",
+			Timestamp:  time.Now().UnixMilli(),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+}
+
+// newProblem builds a problem for code; the title is the HTTP status text
+func newProblem(r *http.Request, status int, code, detail string) *api.Problem {
+	return &api.Problem{
+		Type:     api.ProblemType(code),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+// writeProblem writes an RFC 7807 problem+json response
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblemBody(w, newProblem(r, status, code, detail))
+}
+
+func writeProblemBody(w http.ResponseWriter, p *api.Problem) {
+	w.Header().Set("Content-Type", api.ProblemContentType)
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeProblemErr maps err to its problem and writes it
+func writeProblemErr(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := problemStatus(err)
+	writeProblem(w, r, status, code, err.Error())
+}
+
+// problemStatus maps a tester error to an HTTP status and stable code
+func problemStatus(err error) (int, string) {
+	var netErr net.Error
+	var te *tester.TestError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout, api.CodeTimeout
+	case errors.Is(err, errInvalidV2Request):
+		return http.StatusBadRequest, api.CodeInvalidRequest
+	case errors.Is(err, tester.ErrInvalidServerID):
+		return http.StatusBadRequest, api.CodeInvalidServerID
+	case errors.Is(err, tester.ErrServerNotFound):
+		return http.StatusNotFound, api.CodeServerNotFound
+	case errors.Is(err, tester.ErrNoServers):
+		return http.StatusServiceUnavailable, api.CodeNoServers
+	case errors.Is(err, tester.ErrCatalogue):
+		return http.StatusBadGateway, api.CodeCatalogueUnavailable
+	case errors.Is(err, tester.ErrCancelled):
+		return http.StatusConflict, api.CodeCancelled
+	case errors.As(err, &te):
+		return http.StatusBadGateway, te.Type
+	case errors.Is(err, errWhoami):
+		return http.StatusBadGateway, api.CodeWhoamiFailed
+	case errors.Is(err, errAllFamiliesFailed):
+		return http.StatusBadGateway, api.CodeAllFamiliesFailed
+	}
+	return http.StatusInternalServerError, api.CodeInternal
+}
+
+var (
+	errInvalidV2Request  = errors.New("invalid request")
+	errWhoami            = errors.New("whoami failed")
+	errAllFamiliesFailed = errors.New("IPv4 and IPv6 tests failed")
+)
+
+// v2DualStack runs test per family; per-family failures become inline problems
+func v2DualStack(ctx context.Context, r *http.Request, opts *testOptions,
+	test func(ctx context.Context, o *testOptions) (interface{}, error)) (interface{}, error) {
+	data := &api.DualStackData{IPVersion: IPVersionBoth}
+	failed := 0
+	_, err := speedTester.DualStack(ctx, opts, func(ctx context.Context, o *testOptions) (interface{}, error) {
+		family := &api.FamilyResult{}
+		if result, err := test(ctx, o); err != nil {
+			slog.WarnContext(ctx, "dual-stack family failed", "ip_version", o.IPVersion, "error", err)
+			status, code := problemStatus(err)
+			family.Error = newProblem(r, status, code, err.Error())
+			failed++
+		} else {
+			family.Data = result
+		}
+		if o.IPVersion == IPVersion4 {
+			data.IPv4 = family
+		} else {
+			data.IPv6 = family
+		}
+		return family, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failed == 2 {
+		return nil, fmt.Errorf("%w: IPv4: %s; IPv6: %s", errAllFamiliesFailed, data.IPv4.Error.Detail, data.IPv6.Error.Detail)
+	}
+	return data, nil
+}
+
+// ==================== v2 Handlers ====================
+
+func v2StatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, http.StatusMethodNotAllowed, api.CodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	writeEnvelope(w, http.StatusOK, serviceStatus(), time.Now())
+}
+
+// v2NotFoundHandler answers unknown /api/v2/ paths
+func v2NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, api.CodeNotFound, "No such endpoint: "+r.URL.Path)
+}
+
+var v2PingHandler = v2Handler("ping", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	ping := func(ctx context.Context, o *testOptions) (interface{}, error) {
+		response, err := speedTester.Ping(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		response.Status = "" // v1 only; the envelope says it succeeded
+		return response, nil
+	}
+	if opts.IPVersion == IPVersionBoth {
+		return v2DualStack(ctx, r, opts, ping)
+	}
+	return ping(ctx, opts)
+})
+
+var v2DownloadHandler = v2Handler("download", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	download := func(ctx context.Context, o *testOptions) (interface{}, error) {
+		return speedTester.Download(ctx, &tester.TransferOptions{Options: *o})
+	}
+	if opts.IPVersion == IPVersionBoth {
+		return v2DualStack(ctx, r, opts, download)
+	}
+	return download(ctx, opts)
+})
+
+var v2UploadHandler = v2Handler("upload", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	upload := func(ctx context.Context, o *testOptions) (interface{}, error) {
+		return speedTester.Upload(ctx, &tester.TransferOptions{Options: *o})
+	}
+	if opts.IPVersion == IPVersionBoth {
+		return v2DualStack(ctx, r, opts, upload)
+	}
+	return upload(ctx, opts)
+})
+
+var v2FullHandler = v2Handler("full", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	if opts.IPVersion == IPVersionBoth {
+		return nil, fmt.Errorf("%w: ip_version=both is not supported for full tests", errInvalidV2Request)
+	}
+	return speedTester.Full(ctx, &tester.FullOptions{Options: *opts})
+})
+
+var v2ServersHandler = v2Handler("servers", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	servers, err := speedTester.Servers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	serverList := tester.ServerInfoList(servers, 10)
+	return api.ServersResponse{
+		Count:           len(serverList),
+		Servers:         serverList,
+		AssumedLocation: opts.Location,
+	}, nil
+})
+
+var v2WhoamiHandler = v2Handler("whoami", func(ctx context.Context, r *http.Request, opts *testOptions) (interface{}, error) {
+	refresh := r.URL.Query().Get("refresh") == "1"
+	info, err := speedTester.ClientInfo(ctx, opts, refresh)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errWhoami, err)
+	}
+	return info, nil
+})
+
+func v2LinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, http.StatusMethodNotAllowed, api.CodeMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	writeEnvelope(w, http.StatusOK, linksResponse(), time.Now())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"go-speedtest/tester"
+)
+
+// ==================== Client Info ====================
+
+// speedtestWhoamiHandler - GET /speedtest/whoami
+// Returns public IP, ISP and coordinates as seen by speedtest.net
+// Optional query: ?refresh=1 untuk bypass cache
+func speedtestWhoamiHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	opts, err := parseTestOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	refresh := r.URL.Query().Get("refresh") == "1"
+	info, err := speedTester.ClientInfo(r.Context(), opts, refresh)
+	if err != nil {
+		slog.WarnContext(r.Context(), "whoami failed", "error", err)
+		errType := "whoami_failed"
+		if errors.Is(err, tester.ErrProxy) {
+			errType = "proxy_failed"
+		}
+		writeError(w, http.StatusServiceUnavailable, errType, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-speedtest/api"
+	"go-speedtest/tester"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ==================== WebSocket Transport ====================
+
+// wsWriteTimeout bounds a single WebSocket write
+const wsWriteTimeout = 10 * time.Second
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Same policy as the HTTP API (CORS_ALLOWED_ORIGINS)
+	CheckOrigin: checkWebSocketOrigin,
+}
+
+// wsSession is one WebSocket connection; tests run one after another
+type wsSession struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	interval atomic.Int64 // minimum time between progress events (ns)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // running test, nil when idle
+	done   chan struct{}      // closed when the running test returns
+}
+
+// send writes an event; errors surface as a failed read in the command loop
+func (s *wsSession) send(event StreamEvent) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	s.conn.WriteJSON(event)
+}
+
+func (s *wsSession) sendError(format string, args ...interface{}) {
+	s.send(StreamEvent{Type: api.EventError, Message: fmt.Sprintf(format, args...)})
+}
+
+// throttled drops progress events closer together than the current interval
+func (s *wsSession) throttled() eventSink {
+	var last time.Time
+	return func(event StreamEvent) {
+		if event.Type == api.EventProgress {
+			interval := time.Duration(s.interval.Load())
+			if interval > 0 && time.Since(last) < interval {
+				return
+			}
+			last = time.Now()
+		}
+		s.send(event)
+	}
+}
+
+// start runs a test in the background; fails if one is already running
+func (s *wsSession) start(ctx context.Context, cmd *api.WSCommand) {
+	var upload bool
+	switch cmd.Test {
+	case "download":
+	case "upload":
+		upload = true
+	default:
+		s.sendError("invalid test %q (use download or upload)", cmd.Test)
+		return
+	}
+	if cmd.IntervalMs < 0 {
+		s.sendError("invalid interval_ms %d", cmd.IntervalMs)
+		return
+	}
+
+	q := url.Values{}
+	for k, v := range cmd.Options {
+		q.Set(k, v)
+	}
+	opts, err := newTestOptions(q)
+	if err != nil {
+		s.sendError("%v", err)
+		return
+	}
+	testDuration := parseStreamDuration(q.Get("duration"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.sendError("a test is already running; cancel it first")
+		return
+	}
+
+	s.interval.Store(int64(time.Duration(cmd.IntervalMs) * time.Millisecond))
+	testCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+
+	slog.InfoContext(ctx, "ws: starting test", "test", cmd.Test, "duration_sec", testDuration)
+
+	go func() {
+		defer close(done)
+		defer func() {
+			s.mu.Lock()
+			s.cancel, s.done = nil, nil
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		runTransferStream(testCtx, s.throttled(), opts, upload, testDuration)
+		if testCtx.Err() != nil && ctx.Err() == nil {
+			slog.InfoContext(ctx, "ws: test cancelled", "test", cmd.Test)
+			s.send(StreamEvent{Type: api.EventCancelled, IPVersion: opts.IPVersion})
+		}
+	}()
+}
+
+// stop cancels the running test and waits for it; reports whether one was running
+func (s *wsSession) stop() bool {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return true
+}
+
+// speedtestWSHandler - GET /speedtest/ws
+// WebSocket: client sends WSCommand messages (start, cancel, interval),
+// server sends the same StreamEvent messages as the SSE streams
+func speedtestWSHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote the HTTP error
+		slog.WarnContext(r.Context(), "ws upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// The connection outlives r.Context() once hijacked
+	ctx := withRequestID(context.Background(), requestID(r.Context()))
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(r.Context()))
+	ctx, cancel := context.WithCancel(tester.WithRequester(ctx, "ws "+r.RemoteAddr))
+	session := &wsSession{conn: conn}
+	defer func() {
+		cancel()
+		session.stop()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.InfoContext(ctx, "ws connection closed", "error", err)
+			}
+			return
+		}
+
+		var cmd api.WSCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			session.sendError("invalid command: %v", err)
+			continue
+		}
+
+		switch cmd.Action {
+		case api.ActionStart:
+			session.start(ctx, &cmd)
+		case api.ActionCancel:
+			if !session.stop() {
+				session.sendError("no test is running")
+			}
+		case api.ActionInterval:
+			if cmd.IntervalMs < 0 {
+				session.sendError("invalid interval_ms %d", cmd.IntervalMs)
+				continue
+			}
+			session.interval.Store(int64(time.Duration(cmd.IntervalMs) * time.Millisecond))
+		default:
+			session.sendError("invalid action %q (use start, cancel or interval)", cmd.Action)
+		}
+	}
+}